@@ -2,68 +2,241 @@ package autoMigrate
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"sync"
 
+	"github.com/glebarez/sqlite"
 	_ "github.com/gogf/gf/contrib/drivers/mysql/v2"
+	_ "github.com/gogf/gf/contrib/drivers/pgsql/v2"
+	_ "github.com/gogf/gf/contrib/drivers/sqlite/v2"
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/encoding/gurl"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/os/gcfg"
 	"github.com/gogf/gf/v2/os/gctx"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/gogf/gf/v2/text/gstr"
+	"github.com/gogf/gf/v2/util/gconv"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var (
-	ctx context.Context
+	ctx = gctx.New()
 	db  *gorm.DB
 )
 
 type AutoMigrate struct {
-	ctx context.Context
-	db  *gorm.DB
-	dns string
+	ctx     context.Context
+	db      *gorm.DB
+	dns     string
+	dialect string
 }
 
 var am *AutoMigrate
 
-func New() {
-	am = &AutoMigrate{}
-	am.ctx = gctx.New()
-	err := g.DB().PingMaster()
+// Dialect 根据gdb配置节点创建gorm方言
+type Dialect func(node *gdb.ConfigNode) (gorm.Dialector, error)
+
+var (
+	dialects = map[string]Dialect{
+		"mysql":  mysqlDialect,
+		"sqlite": sqliteDialect,
+		"pgsql":  pgsqlDialect,
+	}
+	dialectMutex sync.RWMutex
+)
+
+// tableOptions 各数据库建表附加选项，未配置的数据库不附加
+var tableOptions = map[string]string{
+	"mysql": "ENGINE=InnoDB",
+}
+
+// SqlitePragmas sqlite默认PRAGMA，gdb配置节点的extra中同名项优先
+var SqlitePragmas = map[string]string{
+	"journal_mode": "WAL",
+	"foreign_keys": "1",
+	"busy_timeout": "5000",
+}
+
+// RegisterDialect 注册数据库类型对应的gorm方言
+/*
+ * @param dbType string 数据库类型，与gdb配置的type一致，如mysql、sqlite、pgsql
+ * @param dialect Dialect 方言创建方法
+ */
+func RegisterDialect(dbType string, dialect Dialect) {
+	dialectMutex.Lock()
+	defer dialectMutex.Unlock()
+	dialects[dbType] = dialect
+}
+
+// mysqlDialect 优先使用配置文件中的dns，未配置时根据gdb配置节点拼接
+func mysqlDialect(node *gdb.ConfigNode) (gorm.Dialector, error) {
+	dns, err := gcfg.Instance().Get(ctx, "dns", "")
 	if err != nil {
+		return nil, err
+	}
+	dsn := dns.String()
+	if dsn == "" {
+		if node.Host == "" {
+			return nil, fmt.Errorf("gormDNS未配置，请检查配置文件")
+		}
+		port := node.Port
+		if port == "" {
+			port = "3306"
+		}
+		charset := node.Charset
+		if charset == "" {
+			charset = "utf8mb4"
+		}
+		dsn = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=True&loc=Local",
+			node.User, node.Pass, node.Host, port, node.Name, charset)
+	}
+	return mysql.New(mysql.Config{
+		DSN:               dsn,
+		DefaultStringSize: 255,
+	}), nil
+}
+
+// sqliteDialect 使用与gdb相同的数据库文件，并附加SqlitePragmas
+func sqliteDialect(node *gdb.ConfigNode) (gorm.Dialector, error) {
+	if node.Name == "" {
+		return nil, fmt.Errorf("sqlite数据库文件路径未配置")
+	}
+	source := node.Name
+	if absolutePath, _ := gfile.Search(source); absolutePath != "" {
+		source = absolutePath
+	}
+	pragmas := make(map[string]string, len(SqlitePragmas))
+	for k, v := range SqlitePragmas {
+		pragmas[k] = v
+	}
+	if node.Extra != "" {
+		extra, err := gstr.Parse(node.Extra)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range extra {
+			pragmas[k] = gconv.String(v)
+		}
+	}
+	var options []string
+	for k, v := range pragmas {
+		options = append(options, fmt.Sprintf("_pragma=%s(%s)", k, gurl.Encode(v)))
+	}
+	if len(options) > 0 {
+		source += "?" + gstr.Join(options, "&")
+	}
+	return sqlite.Open(source), nil
+}
+
+// pgsqlDialect 根据gdb配置节点拼接Postgres连接串，extra中的参数附加到连接串，如sslmode=require
+func pgsqlDialect(node *gdb.ConfigNode) (gorm.Dialector, error) {
+	if node.Host == "" {
+		return nil, fmt.Errorf("pgsql数据库地址未配置")
+	}
+	options := map[string]string{
+		"host":     node.Host,
+		"user":     node.User,
+		"password": node.Pass,
+		"sslmode":  "disable",
+	}
+	if node.Port != "" {
+		options["port"] = node.Port
+	}
+	if node.Name != "" {
+		options["dbname"] = node.Name
+	}
+	if node.Namespace != "" {
+		options["search_path"] = node.Namespace
+	}
+	if node.Timezone != "" {
+		options["TimeZone"] = node.Timezone
+	}
+	if node.Extra != "" {
+		extra, err := gstr.Parse(node.Extra)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range extra {
+			options[k] = gconv.String(v)
+		}
+	}
+	keys := make([]string, 0, len(options))
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	items := make([]string, 0, len(keys))
+	for _, k := range keys {
+		items = append(items, k+"="+pgsqlQuote(options[k]))
+	}
+	return postgres.Open(gstr.Join(items, " ")), nil
+}
+
+// pgsqlQuote 按libpq连接串keyword/value格式对值加单引号，并转义\与'
+func pgsqlQuote(value string) string {
+	return "'" + gstr.ReplaceByArray(value, []string{`\`, `\\`, `'`, `\'`}) + "'"
+}
+
+// New 根据gdb默认分组的配置创建gorm连接
+func New() error {
+	am = &AutoMigrate{ctx: ctx}
+	if err := g.DB().PingMaster(); err != nil {
 		g.Log().Error(ctx, "数据库连接失败", err)
-		return
+		return err
 	}
-	dns, err := gcfg.Instance().Get(ctx, "dns", "")
+	node := g.DB().GetConfig()
+	dialectMutex.RLock()
+	dialect, ok := dialects[node.Type]
+	dialectMutex.RUnlock()
+	if !ok {
+		err := fmt.Errorf("不支持的数据库类型: %s", node.Type)
+		g.Log().Error(ctx, "gorm连接数据库失败", err)
+		return err
+	}
+	dialector, err := dialect(node)
 	if err != nil {
 		g.Log().Error(ctx, "获取配置失败", err)
-		return
+		return err
 	}
-	if g.IsEmpty(dns) {
-		g.Log().Error(ctx, "gormDNS未配置", "请检查配置文件")
-		return
+	am.dialect = node.Type
+	if d, ok := dialector.(*mysql.Dialector); ok {
+		am.dns = d.DSN
 	}
-	am.dns = dns.String()
-	am.db, err = gorm.Open(mysql.New(mysql.Config{
-		DSN:               dns.String(),
-		DefaultStringSize: 255,
-	}), &gorm.Config{})
+	am.db, err = gorm.Open(dialector, &gorm.Config{})
 	if err != nil {
 		g.Log().Error(ctx, "gorm连接数据库失败", err)
-		return
+		return err
 	}
+	return nil
 }
-func SetAutoMigrate(models ...interface{}) {
-	New()
-	if g.IsNil(am.db) {
-		g.Log().Error(ctx, "数据库连接失败")
-		return
+
+// Migrate 迁移数据表结构，返回迁移过程中的错误
+func Migrate(models ...interface{}) error {
+	if err := New(); err != nil {
+		return err
 	}
-	db = am.db.Set("gorm:table_options", "ENGINE=InnoDB")
-	err := db.AutoMigrate(models...)
+	db = am.db
+	if options, ok := tableOptions[am.dialect]; ok {
+		db = db.Set("gorm:table_options", options)
+	}
+	return db.AutoMigrate(models...)
+}
+
+func SetAutoMigrate(models ...interface{}) {
+	err := Migrate(models...)
 	if err != nil {
 		g.Log().Error(ctx, "数据库迁移失败", err)
 	}
 }
 func RenameColumn(dst interface{}, name, newName string) {
+	if am == nil || am.db == nil {
+		g.Log().Error(ctx, "数据库未连接")
+		return
+	}
 	if am.db.Migrator().HasColumn(dst, name) {
 		err := am.db.Migrator().RenameColumn(dst, name, newName)
 		if err != nil {
@@ -78,6 +251,10 @@ func RenameColumn(dst interface{}, name, newName string) {
 // 删除字段
 // 例：DropColumn(&User{}, "Sex")
 func DropColumn(dst interface{}, name string) {
+	if am == nil || am.db == nil {
+		g.Log().Error(ctx, "数据库未连接")
+		return
+	}
 	if am.db.Migrator().HasColumn(dst, name) {
 		err := am.db.Migrator().DropColumn(dst, name)
 		if err != nil {
@@ -0,0 +1,87 @@
+package autoMigrate
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gogf/gf/v2/database/gdb"
+)
+
+type migrateUser struct {
+	Id   uint   `gorm:"primaryKey"`
+	Name string `gorm:"size:64;index"`
+	Age  int
+}
+
+type migrateUserV2 struct {
+	Id    uint   `gorm:"primaryKey"`
+	Name  string `gorm:"size:64;index"`
+	Age   int
+	Email string `gorm:"size:128"`
+}
+
+func (migrateUserV2) TableName() string {
+	return "migrate_users"
+}
+
+// TestMigrateSqlite 使用临时sqlite文件迁移，校验表结构、PRAGMA及重复迁移
+func TestMigrateSqlite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migrate.db")
+	if err := gdb.SetConfig(gdb.Config{
+		gdb.DefaultGroupName: gdb.ConfigGroup{{Type: "sqlite", Name: path}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := New(); err != nil {
+		t.Fatalf("创建gorm连接失败: %v", err)
+	}
+	if am.dialect != "sqlite" {
+		t.Fatalf("dialect = %s, want sqlite", am.dialect)
+	}
+	var journalMode string
+	if err := am.db.Raw("PRAGMA journal_mode").Scan(&journalMode).Error; err != nil {
+		t.Fatal(err)
+	}
+	if journalMode != "wal" {
+		t.Errorf("journal_mode = %s, want wal", journalMode)
+	}
+
+	if err := Migrate(&migrateUser{}); err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	migrator := am.db.Migrator()
+	if !migrator.HasTable(&migrateUser{}) {
+		t.Fatal("迁移后数据表不存在")
+	}
+	for _, column := range []string{"id", "name", "age"} {
+		if !migrator.HasColumn(&migrateUser{}, column) {
+			t.Errorf("缺少字段 %s", column)
+		}
+	}
+	if !migrator.HasIndex(&migrateUser{}, "Name") {
+		t.Error("缺少name索引")
+	}
+	if err := am.db.Create(&migrateUser{Name: "a", Age: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 重复迁移不报错且不丢数据
+	if err := Migrate(&migrateUser{}); err != nil {
+		t.Fatalf("重复迁移失败: %v", err)
+	}
+	// 新增字段
+	if err := Migrate(&migrateUserV2{}); err != nil {
+		t.Fatalf("新增字段迁移失败: %v", err)
+	}
+	if !am.db.Migrator().HasColumn(&migrateUserV2{}, "email") {
+		t.Error("缺少新增字段 email")
+	}
+	var count int64
+	if err := am.db.Model(&migrateUserV2{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("迁移后数据行数 = %d, want 1", count)
+	}
+}
@@ -0,0 +1,165 @@
+package captcha
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/text/gstr"
+	"github.com/gogf/gf/v2/util/grand"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+// Type 验证码类型
+type Type string
+
+const (
+	TypeAlphanumeric Type = "alphanumeric" // 字母数字
+	TypeDigit        Type = "digit"        // 纯数字
+	TypeArithmetic   Type = "arithmetic"   // 算术题，答案为计算结果
+)
+
+// alphanumeric 去除了0、O、1、I等易混淆字符
+const alphanumeric = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// Config 验证码配置
+type Config struct {
+	Type   Type          // 验证码类型，默认字母数字
+	Length int           // 字符长度，算术题无效，默认4
+	Width  int           // 图片宽度，默认120
+	Height int           // 图片高度，默认40
+	Noise  int           // 干扰线数量，默认4
+	Expire time.Duration // 有效期，默认5分钟
+	Store  Store         // 答案存储，默认内存
+}
+
+// Result 生成的验证码
+type Result struct {
+	Id    string `json:"id" dc:"验证码ID，校验时提交"`
+	Image string `json:"image" dc:"验证码图片，data:image/png;base64格式"`
+}
+
+// Captcha 验证码服务
+type Captcha struct {
+	config Config
+	mutex  sync.RWMutex
+}
+
+// Default 默认验证码服务
+var Default = New(Config{})
+
+// New 创建验证码服务
+func New(config Config) *Captcha {
+	c := &Captcha{}
+	c.Init(config)
+	return c
+}
+
+// Init 设置验证码配置，未设置的项使用默认值
+func (c *Captcha) Init(config Config) {
+	if config.Type == "" {
+		config.Type = TypeAlphanumeric
+	}
+	if config.Length <= 0 {
+		config.Length = 4
+	}
+	if config.Width <= 0 {
+		config.Width = 120
+	}
+	if config.Height <= 0 {
+		config.Height = 40
+	}
+	if config.Noise < 0 {
+		config.Noise = 0
+	} else if config.Noise == 0 {
+		config.Noise = 4
+	}
+	if config.Expire <= 0 {
+		config.Expire = 5 * time.Minute
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.config = config
+}
+
+// Generate 生成验证码，答案保存在服务端
+/*
+ * @param ctx context.Context 上下文
+ * @return *Result 验证码ID与图片
+ */
+func (c *Captcha) Generate(ctx context.Context) (*Result, error) {
+	c.mutex.RLock()
+	config := c.config
+	c.mutex.RUnlock()
+	text, answer := question(config.Type, config.Length)
+	image, err := render(text, config.Width, config.Height, config.Noise)
+	if err != nil {
+		return nil, fmt.Errorf("生成验证码失败: %w", err)
+	}
+	id := guid.S()
+	if err = config.Store.Set(ctx, id, answer, config.Expire); err != nil {
+		return nil, fmt.Errorf("保存验证码失败: %w", err)
+	}
+	return &Result{Id: id, Image: image}, nil
+}
+
+// Verify 校验验证码，不区分大小写，无论成功与否验证码都会失效
+/*
+ * @param ctx context.Context 上下文
+ * @param id string 验证码ID
+ * @param answer string 用户输入
+ * @return bool 是否正确
+ */
+func (c *Captcha) Verify(ctx context.Context, id string, answer string) bool {
+	if id == "" || answer == "" {
+		return false
+	}
+	c.mutex.RLock()
+	store := c.config.Store
+	c.mutex.RUnlock()
+	expected, err := store.Take(ctx, id)
+	if err != nil || expected == "" {
+		return false
+	}
+	return gstr.Equal(expected, gstr.Trim(answer))
+}
+
+// Generate 使用默认服务生成验证码
+func Generate(ctx context.Context) (*Result, error) {
+	return Default.Generate(ctx)
+}
+
+// Verify 使用默认服务校验验证码
+func Verify(ctx context.Context, id string, answer string) bool {
+	return Default.Verify(ctx, id, answer)
+}
+
+// question 生成题目与答案
+func question(t Type, length int) (text string, answer string) {
+	switch t {
+	case TypeDigit:
+		text = grand.Digits(length)
+		return text, text
+	case TypeArithmetic:
+		a, b := rand.IntN(9)+1, rand.IntN(9)+1
+		switch rand.IntN(3) {
+		case 0:
+			return fmt.Sprintf("%d+%d=?", a, b), fmt.Sprint(a + b)
+		case 1:
+			if a < b {
+				a, b = b, a
+			}
+			return fmt.Sprintf("%d-%d=?", a, b), fmt.Sprint(a - b)
+		default:
+			return fmt.Sprintf("%dx%d=?", a, b), fmt.Sprint(a * b)
+		}
+	default:
+		text = grand.Str(alphanumeric, length)
+		return text, text
+	}
+}
@@ -0,0 +1,108 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+
+	"github.com/black1552/base-common/server"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gtime"
+)
+
+// Policy 判断登录是否需要验证码，sessionKey与server.LoginCountSession一致，如admin、user
+type Policy func(ctx context.Context, sessionKey string) bool
+
+// LoginPolicy 登录验证码策略，默认登录失败1次后需要验证码
+var LoginPolicy Policy = FailedLoginPolicy(1)
+
+// ErrRequired、ErrInvalid 登录验证码错误
+var (
+	ErrRequired = errors.New("请输入验证码")
+	ErrInvalid  = errors.New("验证码错误或已过期")
+)
+
+// Always 始终需要验证码
+func Always(ctx context.Context, sessionKey string) bool {
+	return true
+}
+
+// FailedLoginPolicy 5分钟内登录失败次数达到times后需要验证码
+// 失败次数由server.LoginCountSession记录
+func FailedLoginPolicy(times int) Policy {
+	return func(ctx context.Context, sessionKey string) bool {
+		r := g.RequestFromCtx(ctx)
+		if r == nil {
+			return true
+		}
+		loginTime, err := r.Session.Get(sessionKey+"LoginTime", 0)
+		if err != nil || gtime.Now().Timestamp()-loginTime.Int64() > 300 {
+			return false
+		}
+		number, err := r.Session.Get(sessionKey+"LoginNum", 0)
+		if err != nil {
+			return false
+		}
+		return number.Int() >= times
+	}
+}
+
+// LoginRequired 当前请求登录时是否需要验证码
+func LoginRequired(ctx context.Context, sessionKey string) bool {
+	return LoginPolicy != nil && LoginPolicy(ctx, sessionKey)
+}
+
+// VerifyLogin 登录时校验验证码，不需要验证码时直接通过
+/*
+ * 例：
+ *	server.AuthLoginSession(ctx, "admin")
+ *	if err := captcha.VerifyLogin(ctx, "admin", req.CaptchaId, req.Captcha); err != nil {
+ *		return nil, err
+ *	}
+ *	// 密码错误时调用server.LoginCountSession(ctx, "admin")记录失败次数
+ * @param ctx context.Context 请求上下文
+ * @param sessionKey string 登录信息的session键
+ * @param id string 验证码ID
+ * @param answer string 用户输入
+ */
+func VerifyLogin(ctx context.Context, sessionKey string, id string, answer string) error {
+	if !LoginRequired(ctx, sessionKey) {
+		return nil
+	}
+	if id == "" || answer == "" {
+		return ErrRequired
+	}
+	if !Default.Verify(ctx, id, answer) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// Handler 获取验证码接口，返回id与image
+func Handler(r *ghttp.Request) {
+	result, err := Default.Generate(r.Context())
+	if err != nil {
+		server.Error(r.Context()).SetMsg(err.Error()).End()
+		return
+	}
+	server.Success(r.Context()).SetMsg("操作成功").SetData(result).End()
+}
+
+// LoginHandler 登录页获取验证码接口，required为false时无需展示验证码
+/*
+ * @param sessionKey string 登录信息的session键
+ */
+func LoginHandler(sessionKey string) ghttp.HandlerFunc {
+	return func(r *ghttp.Request) {
+		data := g.Map{"required": LoginRequired(r.Context(), sessionKey)}
+		if data["required"] == true {
+			result, err := Default.Generate(r.Context())
+			if err != nil {
+				server.Error(r.Context()).SetMsg(err.Error()).End()
+				return
+			}
+			data["id"], data["image"] = result.Id, result.Image
+		}
+		server.Success(r.Context()).SetMsg("操作成功").SetData(data).End()
+	}
+}
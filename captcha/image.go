@@ -0,0 +1,144 @@
+package captcha
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"math/rand/v2"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// glyphWidth、glyphHeight basicfont字形尺寸
+const (
+	glyphWidth  = 7
+	glyphHeight = 13
+)
+
+// glyphMask 获取字符的点阵
+func glyphMask(ch rune) *image.Alpha {
+	mask := image.NewAlpha(image.Rect(0, 0, glyphWidth, glyphHeight))
+	d := font.Drawer{
+		Dst:  mask,
+		Src:  image.Opaque,
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(0, basicfont.Face7x13.Ascent),
+	}
+	d.DrawString(string(ch))
+	return mask
+}
+
+// randColor 生成随机颜色，min、max控制亮度范围
+func randColor(min, max int) color.RGBA {
+	n := func() uint8 { return uint8(min + rand.IntN(max-min)) }
+	return color.RGBA{R: n(), G: n(), B: n(), A: 255}
+}
+
+// drawText 绘制带随机旋转和缩放的文字
+func drawText(img *image.RGBA, text string) {
+	var (
+		bounds = img.Bounds()
+		runes  = []rune(text)
+		cell   = float64(bounds.Dx()) / float64(len(runes)+1)
+		scale  = float64(bounds.Dy()) * 0.65 / glyphHeight
+	)
+	if s := cell * 1.1 / glyphWidth; s < scale {
+		scale = s
+	}
+	for i, ch := range runes {
+		var (
+			mask  = glyphMask(ch)
+			c     = randColor(10, 120)
+			angle = (rand.Float64() - 0.5) * 0.6
+			size  = scale * (0.9 + rand.Float64()*0.25)
+			cx    = cell*(float64(i)+1) + (rand.Float64()-0.5)*cell*0.2
+			cy    = float64(bounds.Dy())/2 + (rand.Float64()-0.5)*float64(bounds.Dy())*0.15
+			sin   = math.Sin(angle)
+			cos   = math.Cos(angle)
+			half  = math.Hypot(glyphWidth, glyphHeight) * size / 2
+		)
+		// 逆向映射：遍历目标区域，旋转缩放回字形坐标取点
+		for y := int(cy - half); y <= int(cy+half); y++ {
+			for x := int(cx - half); x <= int(cx+half); x++ {
+				if !(image.Point{X: x, Y: y}).In(bounds) {
+					continue
+				}
+				dx, dy := float64(x)-cx, float64(y)-cy
+				gx := (dx*cos+dy*sin)/size + glyphWidth/2
+				gy := (-dx*sin+dy*cos)/size + glyphHeight/2
+				if gx < 0 || gy < 0 || gx >= glyphWidth || gy >= glyphHeight {
+					continue
+				}
+				if mask.AlphaAt(int(gx), int(gy)).A > 0 {
+					img.SetRGBA(x, y, c)
+				}
+			}
+		}
+	}
+}
+
+// wave 正弦波扭曲
+func wave(src *image.RGBA, background color.RGBA) *image.RGBA {
+	var (
+		bounds = src.Bounds()
+		dst    = image.NewRGBA(bounds)
+		ampX   = 1.5 + rand.Float64()*2
+		ampY   = 1 + rand.Float64()*1.5
+		period = float64(bounds.Dy()) * (0.8 + rand.Float64()*0.6)
+		phase  = rand.Float64() * math.Pi * 2
+	)
+	draw.Draw(dst, bounds, &image.Uniform{C: background}, image.Point{}, draw.Src)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			sx := x + int(ampX*math.Sin(2*math.Pi*float64(y)/period+phase))
+			sy := y + int(ampY*math.Sin(2*math.Pi*float64(x)/period+phase))
+			if (image.Point{X: sx, Y: sy}).In(bounds) {
+				dst.SetRGBA(x, y, src.RGBAAt(sx, sy))
+			}
+		}
+	}
+	return dst
+}
+
+// drawNoise 绘制干扰线和干扰点
+func drawNoise(img *image.RGBA, lines, dots int) {
+	bounds := img.Bounds()
+	for i := 0; i < lines; i++ {
+		var (
+			c      = randColor(60, 180)
+			x1, y1 = rand.Float64() * float64(bounds.Dx()), rand.Float64() * float64(bounds.Dy())
+			x2, y2 = rand.Float64() * float64(bounds.Dx()), rand.Float64() * float64(bounds.Dy())
+			steps  = int(math.Max(math.Abs(x2-x1), math.Abs(y2-y1))) + 1
+		)
+		for s := 0; s <= steps; s++ {
+			t := float64(s) / float64(steps)
+			img.SetRGBA(int(x1+(x2-x1)*t), int(y1+(y2-y1)*t), c)
+		}
+	}
+	for i := 0; i < dots; i++ {
+		img.SetRGBA(rand.IntN(bounds.Dx()), rand.IntN(bounds.Dy()), randColor(0, 255))
+	}
+}
+
+// render 生成验证码图片，返回data:image/png;base64格式
+func render(text string, width, height, noise int) (string, error) {
+	var (
+		background = randColor(220, 255)
+		img        = image.NewRGBA(image.Rect(0, 0, width, height))
+	)
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+	drawText(img, text)
+	img = wave(img, background)
+	drawNoise(img, noise, width*height/30)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,40 @@
+package captcha
+
+import (
+	"context"
+	"time"
+
+	"github.com/gogf/gf/v2/os/gcache"
+)
+
+// Store 验证码答案存储接口，实现该接口即可接入redis等共享存储
+type Store interface {
+	// Set 保存答案
+	Set(ctx context.Context, id string, answer string, expire time.Duration) error
+	// Take 取出并删除答案，不存在或已过期时返回空字符串
+	Take(ctx context.Context, id string) (string, error)
+}
+
+// MemoryStore 内存存储
+type MemoryStore struct {
+	cache *gcache.Cache
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{cache: gcache.New()}
+}
+
+// Set 保存答案
+func (m *MemoryStore) Set(ctx context.Context, id string, answer string, expire time.Duration) error {
+	return m.cache.Set(ctx, id, answer, expire)
+}
+
+// Take 取出并删除答案
+func (m *MemoryStore) Take(ctx context.Context, id string) (string, error) {
+	value, err := m.cache.Remove(ctx, id)
+	if err != nil || value == nil {
+		return "", err
+	}
+	return value.String(), nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/black1552/base-common/server"
+	"github.com/gogf/gf/v2/os/gcmd"
+)
+
+var configCheckCommand = &gcmd.Command{
+	Name:  "check",
+	Usage: "base-common config check [-config manifest/config/config.yaml]",
+	Brief: "校验配置文件，包括引用解析、环境变量覆盖与配置项校验",
+	Arguments: []gcmd.Argument{
+		{Name: "config", Short: "c", Brief: "配置文件路径", Default: "manifest/config/config.yaml"},
+	},
+	Func: runConfigCheck,
+}
+
+// runConfigCheck 校验配置文件
+func runConfigCheck(ctx context.Context, parser *gcmd.Parser) error {
+	path := parser.GetOpt("config", "manifest/config/config.yaml").String()
+	if _, err := server.LoadConfig(path); err != nil {
+		return err
+	}
+	fmt.Println("配置文件校验通过:", path)
+	return nil
+}
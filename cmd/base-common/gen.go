@@ -0,0 +1,443 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"sort"
+	"text/template"
+
+	"github.com/black1552/base-common/server"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/os/gcmd"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/gogf/gf/v2/text/gregex"
+	"github.com/gogf/gf/v2/text/gstr"
+)
+
+var genCrudCommand = &gcmd.Command{
+	Name:  "crud",
+	Usage: "base-common gen crud <table> [-config manifest/config/config.yaml] [-force]",
+	Brief: "根据数据表生成dao、entity、service、api与controller",
+	Description: "读取配置文件中的数据库连接获取表结构，生成以下文件：\n" +
+		"  internal/model/entity/<table>.go  实体\n" +
+		"  internal/dao/<table>.go           实现utils.IDao的dao\n" +
+		"  internal/service/<table>.go       基于utils.Curd的服务\n" +
+		"  api/<table>/v1/<table>.go         请求与返回结构\n" +
+		"  internal/controller/<table>/<table>.go 控制器",
+	Arguments: []gcmd.Argument{
+		{Name: "table", IsArg: true, Brief: "数据表名"},
+		{Name: "config", Short: "c", Brief: "配置文件路径", Default: "manifest/config/config.yaml"},
+		{Name: "group", Short: "g", Brief: "数据库分组", Default: "default"},
+		{Name: "module", Short: "m", Brief: "go模块名，默认读取go.mod"},
+		{Name: "force", Short: "f", Brief: "覆盖已存在的文件", Orphan: true},
+	},
+	Func: runGenCrud,
+}
+
+// genField 生成代码使用的字段信息
+type genField struct {
+	Name    string // 字段名 如UserName
+	ReqName string // 请求结构中的字段名，避免与g.Meta冲突
+	Column  string // 列名 如user_name
+	Json    string // json名 如userName
+	Type    string // go类型
+	Comment string // 字段注释
+	Primary bool   // 是否为主键
+}
+
+// genData 生成代码的模板数据
+type genData struct {
+	Module  string     // go模块名
+	Table   string     // 表名
+	Group   string     // 数据库分组
+	Name    string     // 结构体名 如UserInfo
+	Var     string     // 变量名 如userInfo
+	Package string     // 包名 如userinfo
+	Path    string     // 路由 如/user-info
+	Fields  []genField // 字段
+	Primary genField   // 主键
+	Imports []string   // 字段类型需要的包
+}
+
+// runGenCrud 生成数据表的增删改查代码
+func runGenCrud(ctx context.Context, parser *gcmd.Parser) error {
+	table := parser.GetArg(3).String()
+	if table == "" {
+		return fmt.Errorf("请指定数据表名，例：base-common gen crud user")
+	}
+	module := parser.GetOpt("module").String()
+	if module == "" {
+		module = goModule()
+	}
+	if module == "" {
+		return fmt.Errorf("未找到go.mod，请在项目根目录执行或使用-module指定模块名")
+	}
+	if _, err := server.LoadConfig(parser.GetOpt("config", "manifest/config/config.yaml").String()); err != nil {
+		return err
+	}
+	group := parser.GetOpt("group", "default").String()
+	fields, err := g.DB(group).TableFields(ctx, table)
+	if err != nil {
+		return fmt.Errorf("获取表%s结构失败: %w", table, err)
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("数据表%s不存在", table)
+	}
+
+	name := gstr.CaseCamel(table)
+	data := genData{
+		Module:  module,
+		Table:   table,
+		Group:   group,
+		Name:    name,
+		Var:     gstr.CaseCamelLower(table),
+		Package: gstr.ToLower(name),
+		Path:    "/" + gstr.CaseKebab(table),
+	}
+	list := make([]genField, 0, len(fields))
+	for _, field := range fields {
+		fieldName := gstr.CaseCamel(field.Name)
+		reqName := fieldName
+		if reqName == "Meta" {
+			reqName = "MetaField"
+		}
+		list = append(list, genField{
+			Name:    fieldName,
+			ReqName: reqName,
+			Column:  field.Name,
+			Json:    gstr.CaseCamelLower(field.Name),
+			Type:    goType(field.Type),
+			Comment: gstr.Trim(gstr.Replace(field.Comment, "\n", " ")),
+			Primary: field.Key == "pri",
+		})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return fields[list[i].Column].Index < fields[list[j].Column].Index
+	})
+	data.Fields = list
+	for _, field := range list {
+		switch field.Type {
+		case "*gtime.Time":
+			data.Imports = appendUnique(data.Imports, "github.com/gogf/gf/v2/os/gtime")
+		case "*gjson.Json":
+			data.Imports = appendUnique(data.Imports, "github.com/gogf/gf/v2/encoding/gjson")
+		}
+	}
+	data.Primary = list[0]
+	for _, field := range list {
+		if field.Primary {
+			data.Primary = field
+			break
+		}
+	}
+
+	force := parser.GetOpt("force") != nil
+	files := []struct {
+		path string
+		tpl  string
+	}{
+		{gfile.Join("internal", "model", "entity", table+".go"), entityTemplate},
+		{gfile.Join("internal", "dao", table+".go"), daoTemplate},
+		{gfile.Join("internal", "service", table+".go"), serviceTemplate},
+		{gfile.Join("api", table, "v1", table+".go"), apiTemplate},
+		{gfile.Join("internal", "controller", table, table+".go"), controllerTemplate},
+	}
+	for _, file := range files {
+		if gfile.IsFile(file.path) && !force {
+			fmt.Println("文件已存在，跳过（使用-force覆盖）:", file.path)
+			continue
+		}
+		content, err := renderTemplate(file.tpl, data)
+		if err != nil {
+			return fmt.Errorf("生成%s失败: %w", file.path, err)
+		}
+		if err = gfile.PutBytes(file.path, content); err != nil {
+			return err
+		}
+		fmt.Println("已生成:", file.path)
+	}
+	return nil
+}
+
+// renderTemplate 渲染模板并格式化代码
+func renderTemplate(tpl string, data genData) ([]byte, error) {
+	t, err := template.New("").Parse(tpl)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// appendUnique 追加不重复的元素
+func appendUnique(list []string, item string) []string {
+	for _, v := range list {
+		if v == item {
+			return list
+		}
+	}
+	return append(list, item)
+}
+
+// goModule 读取当前目录go.mod中的模块名
+func goModule() string {
+	if !gfile.IsFile("go.mod") {
+		return ""
+	}
+	match, _ := gregex.MatchString(`(?m)^module\s+(\S+)`, gfile.GetContents("go.mod"))
+	if len(match) < 2 {
+		return ""
+	}
+	return match[1]
+}
+
+// goType 数据库字段类型转换为go类型
+func goType(dbType string) string {
+	t := gstr.ToLower(dbType)
+	if i := gstr.PosI(t, "("); i > 0 {
+		t = t[:i]
+	}
+	unsigned := gstr.Contains(gstr.ToLower(dbType), "unsigned")
+	switch gstr.Trim(gstr.TrimRightStr(t, " unsigned")) {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "int2", "int4", "serial":
+		if unsigned {
+			return "uint"
+		}
+		return "int"
+	case "bigint", "int8", "bigserial":
+		if unsigned {
+			return "uint64"
+		}
+		return "int64"
+	case "float", "double", "decimal", "numeric", "real", "float4", "float8":
+		return "float64"
+	case "bool", "boolean", "bit":
+		return "bool"
+	case "date", "datetime", "timestamp", "timestamptz", "time":
+		return "*gtime.Time"
+	case "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary", "bytea":
+		return "[]byte"
+	case "json", "jsonb":
+		return "*gjson.Json"
+	}
+	return "string"
+}
+
+const entityTemplate = `// Code generated by base-common gen crud.
+
+package entity
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+
+// {{.Name}} {{.Table}}表
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} ` + "`" + `json:"{{.Json}}" orm:"{{.Column}}" dc:"{{.Comment}}"` + "`" + `
+{{- end}}
+}
+`
+
+const daoTemplate = `// Code generated by base-common gen crud.
+
+package dao
+
+import (
+	"context"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/frame/g"
+)
+
+// {{.Var}}Dao {{.Table}}表数据访问，实现utils.IDao
+type {{.Var}}Dao struct {
+	table   string
+	group   string
+	columns {{.Name}}Columns
+}
+
+// {{.Name}}Columns {{.Table}}表字段
+type {{.Name}}Columns struct {
+{{- range .Fields}}
+	{{.Name}} string // {{.Comment}}
+{{- end}}
+}
+
+// {{.Name}} {{.Table}}表dao
+var {{.Name}} = &{{.Var}}Dao{
+	table: "{{.Table}}",
+	group: "{{.Group}}",
+	columns: {{.Name}}Columns{
+{{- range .Fields}}
+		{{.Name}}: "{{.Column}}",
+{{- end}}
+	},
+}
+
+// DB 数据库连接
+func (d *{{.Var}}Dao) DB() gdb.DB {
+	return g.DB(d.group)
+}
+
+// Table 表名
+func (d *{{.Var}}Dao) Table() string {
+	return d.table
+}
+
+// Group 数据库分组
+func (d *{{.Var}}Dao) Group() string {
+	return d.group
+}
+
+// Columns 表字段
+func (d *{{.Var}}Dao) Columns() {{.Name}}Columns {
+	return d.columns
+}
+
+// Ctx 创建查询模型
+func (d *{{.Var}}Dao) Ctx(ctx context.Context) *gdb.Model {
+	return d.DB().Model(d.table).Safe().Ctx(ctx)
+}
+
+// Transaction 事务
+func (d *{{.Var}}Dao) Transaction(ctx context.Context, f func(ctx context.Context, tx gdb.TX) error) (err error) {
+	return d.Ctx(ctx).Transaction(ctx, f)
+}
+`
+
+const serviceTemplate = `// Code generated by base-common gen crud.
+
+package service
+
+import (
+	"{{.Module}}/internal/dao"
+	"{{.Module}}/internal/model/entity"
+
+	"github.com/black1552/base-common/utils"
+)
+
+// s{{.Name}} {{.Table}}表服务
+type s{{.Name}} struct {
+	utils.Curd[entity.{{.Name}}]
+}
+
+// {{.Name}} {{.Table}}表服务
+var {{.Name}} = &s{{.Name}}{
+	Curd: utils.Curd[entity.{{.Name}}]{Dao: dao.{{.Name}}},
+}
+`
+
+const apiTemplate = `// Code generated by base-common gen crud.
+
+package v1
+
+import (
+	"{{.Module}}/internal/model/entity"
+
+	"github.com/black1552/base-common/utils"
+	"github.com/gogf/gf/v2/frame/g"
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+
+// ListReq {{.Table}}分页列表
+type ListReq struct {
+	g.Meta ` + "`" + `path:"{{.Path}}/list" method:"get" tags:"{{.Name}}" summary:"分页列表"` + "`" + `
+	utils.Paginate
+}
+
+// ListRes {{.Table}}分页列表
+type ListRes struct {
+	List  []*entity.{{.Name}} ` + "`" + `json:"list" dc:"列表"` + "`" + `
+	Total int ` + "`" + `json:"total" dc:"总数"` + "`" + `
+}
+
+// GetReq {{.Table}}详情
+type GetReq struct {
+	g.Meta ` + "`" + `path:"{{.Path}}/get" method:"get" tags:"{{.Name}}" summary:"详情"` + "`" + `
+	{{.Primary.ReqName}} {{.Primary.Type}} ` + "`" + `json:"{{.Primary.Json}}" v:"required" dc:"{{.Primary.Comment}}"` + "`" + `
+}
+
+// GetRes {{.Table}}详情
+type GetRes struct {
+	*entity.{{.Name}}
+}
+
+// SaveReq {{.Table}}新增或修改，主键为空时新增
+type SaveReq struct {
+	g.Meta ` + "`" + `path:"{{.Path}}/save" method:"post" tags:"{{.Name}}" summary:"新增或修改"` + "`" + `
+{{- range .Fields}}
+	{{.ReqName}} {{.Type}} ` + "`" + `json:"{{.Json}}" dc:"{{.Comment}}"` + "`" + `
+{{- end}}
+}
+
+// SaveRes {{.Table}}新增或修改
+type SaveRes struct {
+	Id int64 ` + "`" + `json:"id" dc:"主键"` + "`" + `
+}
+
+// DeleteReq {{.Table}}删除
+type DeleteReq struct {
+	g.Meta ` + "`" + `path:"{{.Path}}/delete" method:"post" tags:"{{.Name}}" summary:"删除"` + "`" + `
+	{{.Primary.ReqName}} {{.Primary.Type}} ` + "`" + `json:"{{.Primary.Json}}" v:"required" dc:"{{.Primary.Comment}}"` + "`" + `
+}
+
+// DeleteRes {{.Table}}删除
+type DeleteRes struct{}
+`
+
+const controllerTemplate = `// Code generated by base-common gen crud.
+
+package {{.Package}}
+
+import (
+	"context"
+
+	"{{.Module}}/api/{{.Table}}/v1"
+	"{{.Module}}/internal/service"
+)
+
+// Controller {{.Table}}控制器
+type Controller struct{}
+
+// New 创建控制器，注册：group.Bind({{.Package}}.New())
+func New() *Controller {
+	return &Controller{}
+}
+
+// List 分页列表
+func (c *Controller) List(ctx context.Context, req *v1.ListReq) (res *v1.ListRes, err error) {
+	res = &v1.ListRes{}
+	res.List, res.Total, err = service.{{.Name}}.Paginate(ctx, nil, req.Paginate, false, nil)
+	return
+}
+
+// Get 详情
+func (c *Controller) Get(ctx context.Context, req *v1.GetReq) (res *v1.GetRes, err error) {
+	res = &v1.GetRes{}
+	res.{{.Name}}, err = service.{{.Name}}.FindPri(ctx, req.{{.Primary.ReqName}}, false)
+	return
+}
+
+// Save 新增或修改
+func (c *Controller) Save(ctx context.Context, req *v1.SaveReq) (res *v1.SaveRes, err error) {
+	res = &v1.SaveRes{}
+	res.Id, err = service.{{.Name}}.Save(ctx, req)
+	return
+}
+
+// Delete 删除
+func (c *Controller) Delete(ctx context.Context, req *v1.DeleteReq) (res *v1.DeleteRes, err error) {
+	err = service.{{.Name}}.DeletePri(ctx, req.{{.Primary.ReqName}})
+	return
+}
+`
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/black1552/base-common/server"
+	"github.com/gogf/gf/v2/os/gcmd"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/gogf/gf/v2/text/gstr"
+)
+
+var initCommand = &gcmd.Command{
+	Name:  "init",
+	Usage: "base-common init [-db mysql|sqlite] [-path .] [-force]",
+	Brief: "创建resource目录结构与manifest/config/config.yaml",
+	Description: "未指定-db时交互式选择数据库类型；mysql的密码通过环境变量DB_PASS读取，" +
+		"sqlite数据库文件位于resource/data/app.db",
+	Arguments: []gcmd.Argument{
+		{Name: "db", Short: "d", Brief: "数据库类型 mysql/sqlite"},
+		{Name: "path", Short: "p", Brief: "项目根目录", Default: "."},
+		{Name: "force", Short: "f", Brief: "覆盖已存在的配置文件", Orphan: true},
+	},
+	Func: runInit,
+}
+
+// runInit 初始化项目目录与配置文件
+func runInit(ctx context.Context, parser *gcmd.Parser) error {
+	var (
+		dbType = gstr.ToLower(parser.GetOpt("db").String())
+		root   = gfile.RealPath(parser.GetOpt("path", ".").String())
+		force  = parser.GetOpt("force") != nil
+	)
+	if root == "" {
+		return fmt.Errorf("项目目录不存在: %s", parser.GetOpt("path").String())
+	}
+	for dbType == "" {
+		dbType = gstr.ToLower(gstr.Trim(gcmd.Scan("请选择数据库类型 mysql/sqlite [mysql]: ")))
+		if dbType == "" {
+			dbType = "mysql"
+		}
+		if dbType != "mysql" && dbType != "sqlite" {
+			fmt.Println("仅支持mysql或sqlite")
+			dbType = ""
+		}
+	}
+	if dbType != "mysql" && dbType != "sqlite" {
+		return fmt.Errorf("不支持的数据库类型: %s", dbType)
+	}
+	if err := server.InitResourceDirs(root); err != nil {
+		return err
+	}
+	fmt.Println("目录结构已创建:", gfile.Join(root, "resource"))
+	configPath := gfile.Join(root, "manifest", "config", "config.yaml")
+	written, err := server.WriteDefaultConfig(configPath, server.DefaultDatabase(dbType), force)
+	if err != nil {
+		return err
+	}
+	if !written {
+		fmt.Println("配置文件已存在，未覆盖（使用-force覆盖）:", configPath)
+		return nil
+	}
+	fmt.Println("配置文件已创建:", configPath)
+	if dbType == "mysql" {
+		fmt.Println("数据库密码请通过环境变量DB_PASS设置")
+	}
+	return nil
+}
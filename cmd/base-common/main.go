@@ -0,0 +1,43 @@
+// base-common 项目脚手架命令行工具
+//
+// 安装：go install github.com/black1552/base-common/cmd/base-common@latest
+//
+//	base-common init [-db mysql|sqlite] [-path .] [-force]
+//	base-common gen crud <table> [-config manifest/config/config.yaml] [-force]
+//	base-common config check [-config manifest/config/config.yaml]
+package main
+
+import (
+	"github.com/gogf/gf/v2/os/gcmd"
+	"github.com/gogf/gf/v2/os/gctx"
+)
+
+var root = &gcmd.Command{
+	Name:  "base-common",
+	Usage: "base-common COMMAND [OPTION]",
+	Brief: "base-common项目脚手架",
+}
+
+func main() {
+	ctx := gctx.GetInitCtx()
+	gen := &gcmd.Command{
+		Name:  "gen",
+		Usage: "base-common gen crud <table>",
+		Brief: "代码生成",
+	}
+	config := &gcmd.Command{
+		Name:  "config",
+		Usage: "base-common config check",
+		Brief: "配置文件管理",
+	}
+	if err := gen.AddCommand(genCrudCommand); err != nil {
+		panic(err)
+	}
+	if err := config.AddCommand(configCheckCommand); err != nil {
+		panic(err)
+	}
+	if err := root.AddCommand(initCommand, gen, config); err != nil {
+		panic(err)
+	}
+	root.Run(ctx)
+}
@@ -15,9 +15,8 @@ type Client struct {
 	client     mqtt.Client
 	opts       *mqtt.ClientOptions
 	ctx        context.Context
-	subscribed map[string]byte
+	subscribed map[string]subscription
 	subMutex   sync.RWMutex
-	callback   mqtt.MessageHandler
 	// 错误处理相关
 	onConnectionLost    func(error)
 	onReconnect         func()
@@ -26,11 +25,17 @@ type Client struct {
 	onPublishError      func(error)
 }
 
+// subscription 已订阅主题的QoS及回调，重连后按主题重新订阅
+type subscription struct {
+	qos      byte
+	callback mqtt.MessageHandler
+}
+
 // NewClientWithAuth 创建带用户名密码认证的MQTT客户端
 func NewClientWithAuth(ctx context.Context, broker, clientId, username, password string) *Client {
 	c := &Client{
 		ctx:        ctx,
-		subscribed: make(map[string]byte),
+		subscribed: make(map[string]subscription),
 	}
 
 	opts := mqtt.NewClientOptions()
@@ -104,14 +109,13 @@ func (c *Client) Disconnect() {
 	}
 }
 
-// SubscribeMultiple 同时订阅多个主题
+// SubscribeMultiple 同时订阅多个主题，callback只处理本次订阅的主题，不影响其他主题的回调
 func (c *Client) SubscribeMultiple(topics map[string]byte, callback mqtt.MessageHandler) error {
 	// 保存订阅信息
 	c.subMutex.Lock()
 	for topic, qos := range topics {
-		c.subscribed[topic] = qos
+		c.subscribed[topic] = subscription{qos: qos, callback: callback}
 	}
-	c.callback = callback
 	c.subMutex.Unlock()
 
 	token := c.client.SubscribeMultiple(topics, callback)
@@ -179,33 +183,45 @@ func (c *Client) resubscribe() {
 	}
 
 	// 复制订阅信息避免并发问题
-	topics := make(map[string]byte)
-	for topic, qos := range c.subscribed {
-		topics[topic] = qos
+	subscribed := make(map[string]subscription, len(c.subscribed))
+	for topic, sub := range c.subscribed {
+		subscribed[topic] = sub
 	}
 
-	glog.Info(c.ctx, "开始重新订阅主题:", topics)
-	// 增加重新订阅的超时时间
-	token := c.client.SubscribeMultiple(topics, c.callback)
-	if token.WaitTimeout(30 * time.Second) {
-		if token.Error() != nil {
-			err := fmt.Errorf("重新订阅主题时发生错误: %w", token.Error())
-			glog.Error(c.ctx, "重新订阅主题时发生错误:", token.Error())
+	glog.Info(c.ctx, "开始重新订阅主题:", subscribedTopics(subscribed))
+	// 每个主题使用各自的回调重新订阅
+	for topic, sub := range subscribed {
+		token := c.client.Subscribe(topic, sub.qos, sub.callback)
+		// 增加重新订阅的超时时间
+		if token.WaitTimeout(30 * time.Second) {
+			if token.Error() != nil {
+				err := fmt.Errorf("重新订阅主题%s时发生错误: %w", topic, token.Error())
+				glog.Error(c.ctx, "重新订阅主题", topic, "时发生错误:", token.Error())
+				if c.onSubscriptionError != nil {
+					c.onSubscriptionError(err)
+				}
+				continue
+			}
+			glog.Info(c.ctx, "重新订阅主题成功:", topic)
+		} else {
+			err := fmt.Errorf("重新订阅主题%s超时", topic)
+			glog.Error(c.ctx, "重新订阅主题超时:", topic)
 			if c.onSubscriptionError != nil {
 				c.onSubscriptionError(err)
 			}
-		} else {
-			glog.Info(c.ctx, "重新订阅主题成功")
-		}
-	} else {
-		err := fmt.Errorf("重新订阅主题超时")
-		glog.Error(c.ctx, "重新订阅主题超时")
-		if c.onSubscriptionError != nil {
-			c.onSubscriptionError(err)
 		}
 	}
 }
 
+// subscribedTopics 获取订阅信息中的主题及QoS
+func subscribedTopics(subscribed map[string]subscription) map[string]byte {
+	topics := make(map[string]byte, len(subscribed))
+	for topic, sub := range subscribed {
+		topics[topic] = sub.qos
+	}
+	return topics
+}
+
 // IsConnected 检查是否连接
 func (c *Client) IsConnected() bool {
 	isConnected := c.client.IsConnected()
@@ -218,3 +234,31 @@ func (c *Client) Subscribe(topic string, qos byte, callback mqtt.MessageHandler)
 	topics := map[string]byte{topic: qos}
 	return c.SubscribeMultiple(topics, callback)
 }
+
+// Subscriptions 获取当前已订阅的主题及QoS
+func (c *Client) Subscriptions() map[string]byte {
+	c.subMutex.RLock()
+	defer c.subMutex.RUnlock()
+	return subscribedTopics(c.subscribed)
+}
+
+// Unsubscribe 取消订阅主题，重连后不再重新订阅
+func (c *Client) Unsubscribe(topics ...string) error {
+	c.subMutex.Lock()
+	for _, topic := range topics {
+		delete(c.subscribed, topic)
+	}
+	c.subMutex.Unlock()
+
+	token := c.client.Unsubscribe(topics...)
+	if !token.WaitTimeout(30 * time.Second) {
+		glog.Error(c.ctx, "取消订阅主题超时:", topics)
+		return fmt.Errorf("取消订阅主题超时: %v", topics)
+	}
+	if token.Error() != nil {
+		glog.Error(c.ctx, "取消订阅主题时发生错误:", token.Error())
+		return fmt.Errorf("取消订阅主题出现错误: %w", token.Error())
+	}
+	glog.Info(c.ctx, "成功取消订阅主题:", topics)
+	return nil
+}
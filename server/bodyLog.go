@@ -0,0 +1,278 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/gogf/gf/v2/os/glog"
+	"github.com/gogf/gf/v2/text/gstr"
+)
+
+// BodyLogConfig 请求/响应体日志配置
+type BodyLogConfig struct {
+	Path              string   `yaml:"path" json:"path"`                           // 日志目录，默认resource/log/body
+	File              string   `yaml:"file" json:"file"`                           // 日志文件名格式
+	RotateSize        string   `yaml:"rotateSize" json:"rotateSize"`               // 单个日志文件最大大小，如10M
+	RotateBackupLimit int      `yaml:"rotateBackupLimit" json:"rotateBackupLimit"` // 保留的历史日志数量
+	MaxBodySize       int      `yaml:"maxBodySize" json:"maxBodySize"`             // 请求体/响应体最大记录字节数，超出截断
+	SampleRate        float64  `yaml:"sampleRate" json:"sampleRate"`               // 采样率 0-1，1为全部记录
+	Include           []string `yaml:"include" json:"include"`                     // 只记录的路由，为空则记录全部，支持/api/*前缀匹配
+	Exclude           []string `yaml:"exclude" json:"exclude"`                     // 不记录的路由，优先于Include
+	RedactKeys        []string `yaml:"redactKeys" json:"redactKeys"`               // 需要脱敏的字段名，不区分大小写及下划线
+	RedactValue       string   `yaml:"redactValue" json:"redactValue"`             // 脱敏后的替换值
+}
+
+// DefaultBodyLogConfig 默认请求/响应体日志配置
+func DefaultBodyLogConfig() *BodyLogConfig {
+	return &BodyLogConfig{
+		Path:              gfile.Join(gfile.Pwd(), "resource", "log", "body"),
+		File:              "body-{Y-m-d}.log",
+		RotateSize:        "10M",
+		RotateBackupLimit: 10,
+		MaxBodySize:       4096,
+		SampleRate:        1,
+		Include:           []string{},
+		Exclude:           []string{"/swagger*", "/api.json", "/static/*"},
+		RedactKeys:        []string{"password", "pass", "token", "idCard", "phone"},
+		RedactValue:       "******",
+	}
+}
+
+// bodyLogEntry 单条日志内容
+type bodyLogEntry struct {
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Query    any    `json:"query,omitempty"`
+	Body     any    `json:"body,omitempty"`
+	Status   int    `json:"status"`
+	Response any    `json:"response,omitempty"`
+	Cost     string `json:"cost"`
+	Ip       string `json:"ip"`
+}
+
+// bodyLogCtxKey 等待输出后记录的日志
+const bodyLogCtxKey = "bodyLogPending"
+
+// bodyLogPending 等待记录的日志，响应由外层中间件（如MiddlewareError）输出时在HookBodyLog中记录
+type bodyLogPending struct {
+	entry      bodyLogEntry
+	start      time.Time
+	logger     *glog.Logger
+	config     *BodyLogConfig
+	redactKeys map[string]struct{}
+}
+
+// MiddlewareBodyLog 请求/响应体日志中间件，记录实际输出的响应内容
+// 响应由外层中间件（如Start注册的MiddlewareError）写入时，在输出前由HookBodyLog记录，
+// Start已绑定该钩子，自行创建的服务需绑定：s.BindHookHandler("/*any", ghttp.HookBeforeOutput, server.HookBodyLog)
+// 已Flush的流式响应不记录响应体
+// json及表单内容脱敏后记录，其他类型（如文本、xml）无法脱敏，只记录内容类型和大小
+/*
+ * @param config *BodyLogConfig 日志配置，为nil时使用默认配置
+ * @return ghttp.HandlerFunc 中间件
+ */
+func MiddlewareBodyLog(config *BodyLogConfig) ghttp.HandlerFunc {
+	if config == nil {
+		config = DefaultBodyLogConfig()
+	}
+	logger := glog.New()
+	err := logger.SetConfigWithMap(map[string]any{
+		"path":              config.Path,
+		"file":              config.File,
+		"level":             "all",
+		"stdout":            false,
+		"rotateSize":        config.RotateSize,
+		"rotateBackupLimit": config.RotateBackupLimit,
+		"timeFormat":        "2006-01-02 15:04:05",
+		"writerColorEnable": false,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("设置请求体日志配置失败: %+v", err))
+	}
+	redactKeys := make(map[string]struct{}, len(config.RedactKeys))
+	for _, key := range config.RedactKeys {
+		redactKeys[normalizeRedactKey(key)] = struct{}{}
+	}
+	return func(r *ghttp.Request) {
+		path := r.URL.Path
+		if matchRoutes(config.Exclude, path) ||
+			(len(config.Include) > 0 && !matchRoutes(config.Include, path)) ||
+			(config.SampleRate < 1 && rand.Float64() >= config.SampleRate) {
+			r.Middleware.Next()
+			return
+		}
+		pending := &bodyLogPending{
+			start:      time.Now(),
+			logger:     logger,
+			config:     config,
+			redactKeys: redactKeys,
+			entry: bodyLogEntry{
+				Method: r.Method,
+				Path:   path,
+				// 在业务处理前读取请求体，防止被后续读取影响
+				Body: bodyLogRequest(r, redactKeys, config),
+				Ip:   r.GetClientIp(),
+			},
+		}
+		if r.URL.RawQuery != "" {
+			query, _ := url.ParseQuery(r.URL.RawQuery)
+			pending.entry.Query = limitBody(redactValue(queryMap(query), redactKeys, config.RedactValue), config.MaxBodySize)
+		}
+		r.Middleware.Next()
+
+		// 已有输出或已退出（不会再触发输出钩子）时立即记录，否则等待外层中间件输出
+		if r.Response.BufferLength() > 0 || r.IsExited() {
+			pending.log(r)
+			return
+		}
+		r.SetCtxVar(bodyLogCtxKey, pending)
+	}
+}
+
+// HookBodyLog 输出前记录MiddlewareBodyLog等待的日志，绑定为ghttp.HookBeforeOutput
+func HookBodyLog(r *ghttp.Request) {
+	if pending, ok := r.GetCtxVar(bodyLogCtxKey).Val().(*bodyLogPending); ok {
+		r.SetCtxVar(bodyLogCtxKey, nil)
+		pending.log(r)
+	}
+}
+
+// log 按实际输出补全状态码、响应内容及耗时并写入日志
+func (p *bodyLogPending) log(r *ghttp.Request) {
+	entry := p.entry
+	entry.Status = r.Response.Status
+	if entry.Status == 0 {
+		entry.Status = http.StatusOK
+	}
+	entry.Cost = time.Since(p.start).String()
+	entry.Response = bodyLogResponse(r, p.redactKeys, p.config)
+	p.logger.Info(r.Context(), gjson.MustEncodeString(entry))
+}
+
+// bodyLogRequest 解析请求体并脱敏
+func bodyLogRequest(r *ghttp.Request, redactKeys map[string]struct{}, config *BodyLogConfig) any {
+	contentType := gstr.ToLower(r.Header.Get("Content-Type"))
+	switch {
+	case gstr.Contains(contentType, "multipart/form-data"):
+		// 文件上传只记录表单字段
+		return limitBody(redactValue(r.GetFormMap(), redactKeys, config.RedactValue), config.MaxBodySize)
+	case gstr.Contains(contentType, "application/x-www-form-urlencoded"):
+		return limitBody(redactValue(r.GetFormMap(), redactKeys, config.RedactValue), config.MaxBodySize)
+	}
+	return bodyLogContent(contentType, r.GetBody(), redactKeys, config)
+}
+
+// bodyLogResponse 获取实际输出的响应内容并脱敏，缓冲区为空（无输出或已Flush）时不记录
+func bodyLogResponse(r *ghttp.Request, redactKeys map[string]struct{}, config *BodyLogConfig) any {
+	if r.Response.BufferLength() == 0 {
+		return nil
+	}
+	contentType := gstr.ToLower(r.Response.Header().Get("Content-Type"))
+	return bodyLogContent(contentType, r.Response.Buffer(), redactKeys, config)
+}
+
+// bodyLogContent 按内容类型记录：json及urlencoded表单脱敏后记录，其他类型无法脱敏，只记录类型和大小
+func bodyLogContent(contentType string, raw []byte, redactKeys map[string]struct{}, config *BodyLogConfig) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	switch {
+	case gstr.Contains(contentType, "json") || (contentType == "" && gjson.Valid(raw)):
+		if json, err := gjson.DecodeToJson(raw); err == nil {
+			return limitBody(redactValue(json.Interface(), redactKeys, config.RedactValue), config.MaxBodySize)
+		}
+	case gstr.Contains(contentType, "application/x-www-form-urlencoded"):
+		if form, err := url.ParseQuery(string(raw)); err == nil {
+			return limitBody(redactValue(queryMap(form), redactKeys, config.RedactValue), config.MaxBodySize)
+		}
+	}
+	if contentType == "" {
+		contentType = "unknown"
+	}
+	return fmt.Sprintf("(%s, %d bytes)", contentType, len(raw))
+}
+
+// queryMap 将url.Values转换为map，单个值不使用数组
+func queryMap(query url.Values) map[string]any {
+	values := make(map[string]any, len(query))
+	for k, v := range query {
+		if len(v) == 1 {
+			values[k] = v[0]
+		} else {
+			values[k] = v
+		}
+	}
+	return values
+}
+
+// redactValue 递归脱敏
+func redactValue(value any, redactKeys map[string]struct{}, replace string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			if _, ok := redactKeys[normalizeRedactKey(key)]; ok {
+				result[key] = replace
+				continue
+			}
+			result[key] = redactValue(item, redactKeys, replace)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = redactValue(item, redactKeys, replace)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// normalizeRedactKey 统一字段名格式，id_card、idCard、ID-CARD视为同一字段
+func normalizeRedactKey(key string) string {
+	return gstr.ToLower(gstr.ReplaceByArray(key, []string{"_", "", "-", ""}))
+}
+
+// limitBody 未超长时保留结构化内容，超长时转为截断后的字符串
+func limitBody(value any, size int) any {
+	encoded := gjson.MustEncodeString(value)
+	if size <= 0 || len(encoded) <= size {
+		return value
+	}
+	return truncateBody(encoded, size)
+}
+
+// truncateBody 截断超长内容，在字符边界截断，防止写入不完整的UTF-8字符
+func truncateBody(body string, size int) string {
+	if size <= 0 || len(body) <= size {
+		return body
+	}
+	for size > 0 && !utf8.RuneStart(body[size]) {
+		size--
+	}
+	return body[:size] + "...(truncated)"
+}
+
+// matchRoutes 判断路径是否命中路由列表，以*结尾的规则按前缀匹配
+func matchRoutes(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if gstr.HasSuffix(pattern, "*") {
+			if gstr.HasPrefix(path, gstr.TrimRight(pattern, "*")) {
+				return true
+			}
+			continue
+		}
+		if pattern == path {
+			return true
+		}
+	}
+	return false
+}
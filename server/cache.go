@@ -0,0 +1,278 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/black1552/base-common/utils"
+	"github.com/gogf/gf/v2/crypto/gmd5"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gcache"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// CacheEntry 缓存的响应内容
+type CacheEntry struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+	ETag        string `json:"etag"`
+}
+
+// CacheStore 响应缓存存储接口，实现该接口即可接入redis等共享缓存
+type CacheStore interface {
+	// Get 获取缓存，不存在时返回nil
+	Get(ctx context.Context, key string) (*CacheEntry, error)
+	// Set 设置缓存并关联标签
+	Set(ctx context.Context, key string, entry *CacheEntry, ttl time.Duration, tags []string) error
+	// InvalidateTags 按标签清除缓存
+	InvalidateTags(ctx context.Context, tags ...string) error
+}
+
+// CacheKeyFunc 生成缓存键
+type CacheKeyFunc func(r *ghttp.Request) string
+
+// CacheOption 缓存中间件配置
+type CacheOption struct {
+	TTL     time.Duration                   // 缓存时间
+	Key     CacheKeyFunc                    // 缓存键，默认CacheKeyQuery
+	Tags    []string                        // 缓存标签，用于批量失效，如CacheTableTag("dict")
+	TagFunc func(r *ghttp.Request) []string // 动态标签，与Tags合并
+	Store   CacheStore                      // 缓存存储，默认DefaultCacheStore
+}
+
+// DefaultCacheStore 默认内存缓存
+var DefaultCacheStore CacheStore = NewMemoryCacheStore()
+
+// cachePruneInterval 清理过期缓存键标签关联的间隔
+const cachePruneInterval = time.Minute
+
+// MemoryCacheStore 内存缓存实现
+type MemoryCacheStore struct {
+	cache     *gcache.Cache
+	tags      map[string]map[string]struct{} // tag -> keys
+	keys      map[string]*cacheKey           // key -> 标签及过期时间
+	lastPrune time.Time                      // 上次清理过期键的时间
+	mutex     sync.Mutex                     // 保护tags、keys、lastPrune
+}
+
+// cacheKey 缓存键关联的标签及过期时间
+type cacheKey struct {
+	tags     []string
+	expireAt time.Time // 零值表示不过期
+}
+
+// NewMemoryCacheStore 创建内存缓存
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{
+		cache:     gcache.New(),
+		tags:      make(map[string]map[string]struct{}),
+		keys:      make(map[string]*cacheKey),
+		lastPrune: time.Now(),
+	}
+}
+
+// Get 获取缓存，缓存已过期时同时移除其标签关联
+func (m *MemoryCacheStore) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	value, err := m.cache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if value.IsNil() {
+		m.mutex.Lock()
+		if item, ok := m.keys[key]; ok && item.expired(time.Now()) {
+			m.untrack(key)
+		}
+		m.mutex.Unlock()
+		return nil, nil
+	}
+	entry, _ := value.Val().(*CacheEntry)
+	return entry, nil
+}
+
+// Set 设置缓存，并定期清理已过期缓存键的标签关联
+func (m *MemoryCacheStore) Set(ctx context.Context, key string, entry *CacheEntry, ttl time.Duration, tags []string) error {
+	if err := m.cache.Set(ctx, key, entry, ttl); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := time.Now()
+	m.untrack(key)
+	item := &cacheKey{tags: append([]string(nil), tags...)}
+	if ttl > 0 {
+		item.expireAt = now.Add(ttl)
+	}
+	m.keys[key] = item
+	for _, tag := range tags {
+		if _, ok := m.tags[tag]; !ok {
+			m.tags[tag] = make(map[string]struct{})
+		}
+		m.tags[tag][key] = struct{}{}
+	}
+	if now.Sub(m.lastPrune) >= cachePruneInterval {
+		m.lastPrune = now
+		for k, v := range m.keys {
+			if v.expired(now) {
+				m.untrack(k)
+			}
+		}
+	}
+	return nil
+}
+
+// InvalidateTags 按标签清除缓存
+func (m *MemoryCacheStore) InvalidateTags(ctx context.Context, tags ...string) error {
+	m.mutex.Lock()
+	keys := make([]any, 0)
+	for _, tag := range tags {
+		for key := range m.tags[tag] {
+			keys = append(keys, key)
+			m.untrack(key)
+		}
+	}
+	m.mutex.Unlock()
+	if len(keys) == 0 {
+		return nil
+	}
+	return m.cache.Removes(ctx, keys)
+}
+
+// untrack 移除缓存键的标签关联，需持有mutex
+func (m *MemoryCacheStore) untrack(key string) {
+	item, ok := m.keys[key]
+	if !ok {
+		return
+	}
+	delete(m.keys, key)
+	for _, tag := range item.tags {
+		delete(m.tags[tag], key)
+		if len(m.tags[tag]) == 0 {
+			delete(m.tags, tag)
+		}
+	}
+}
+
+// expired 是否已过期
+func (k *cacheKey) expired(now time.Time) bool {
+	return !k.expireAt.IsZero() && !now.Before(k.expireAt)
+}
+
+// CacheTableTag 数据表对应的缓存标签
+func CacheTableTag(table string) string {
+	return "table:" + table
+}
+
+// CacheInvalidateOnWrite 注册Curd写入回调，数据表变更时清除该表标签下的缓存
+/*
+ * @param store CacheStore 缓存存储，为nil时使用DefaultCacheStore
+ */
+func CacheInvalidateOnWrite(store CacheStore) {
+	if store == nil {
+		store = DefaultCacheStore
+	}
+	utils.OnWrite(func(ctx context.Context, table string) {
+		_ = store.InvalidateTags(ctx, CacheTableTag(table))
+	})
+}
+
+// InvalidateCache 按标签清除默认缓存
+func InvalidateCache(ctx context.Context, tags ...string) error {
+	return DefaultCacheStore.InvalidateTags(ctx, tags...)
+}
+
+// CacheKeyPath 按路径缓存
+func CacheKeyPath(r *ghttp.Request) string {
+	return r.URL.Path
+}
+
+// CacheKeyQuery 按路径和查询参数缓存，参数顺序不影响缓存键
+func CacheKeyQuery(r *ghttp.Request) string {
+	query, _ := url.ParseQuery(r.URL.RawQuery)
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// CacheKeyUser 按路径、查询参数和登录用户缓存
+/*
+ * @param sessionKey string 登录信息的session键，如admin、user
+ */
+func CacheKeyUser(sessionKey string) CacheKeyFunc {
+	return func(r *ghttp.Request) string {
+		user, _ := r.Session.Get(sessionKey, nil)
+		return CacheKeyQuery(r) + "#" + gconv.String(user)
+	}
+}
+
+// MiddlewareCache GET请求响应缓存中间件，支持ETag/304
+/*
+ * 命中缓存时直接输出，不再执行之后注册的中间件及处理函数，
+ * 包括登录校验等权限中间件，需将其注册在缓存中间件之前，或使用CacheKeyUser按用户区分缓存
+ * @param option *CacheOption 缓存配置
+ * @return ghttp.HandlerFunc 中间件
+ */
+func MiddlewareCache(option *CacheOption) ghttp.HandlerFunc {
+	if option.Key == nil {
+		option.Key = CacheKeyQuery
+	}
+	if option.Store == nil {
+		option.Store = DefaultCacheStore
+	}
+	return func(r *ghttp.Request) {
+		if r.Method != http.MethodGet {
+			r.Middleware.Next()
+			return
+		}
+		key := "response:" + option.Key(r)
+		entry, err := option.Store.Get(r.Context(), key)
+		if err == nil && entry != nil {
+			r.Response.Header().Set("X-Cache", "HIT")
+			writeCacheEntry(r, entry)
+			return
+		}
+
+		r.Middleware.Next()
+		if r.GetError() != nil || (r.Response.Status != 0 && r.Response.Status != http.StatusOK) {
+			return
+		}
+		if r.Response.BufferLength() == 0 {
+			res := r.GetHandlerResponse()
+			if res == nil {
+				return
+			}
+			// 与MiddlewareError保持一致的返回格式
+			r.Response.WriteJson(Json{Code: 1, Data: res, Msg: "操作成功"})
+		}
+		body := append([]byte(nil), r.Response.Buffer()...)
+		entry = &CacheEntry{
+			Status:      http.StatusOK,
+			ContentType: r.Response.Header().Get("Content-Type"),
+			Body:        body,
+			ETag:        `"` + gmd5.MustEncrypt(body) + `"`,
+		}
+		tags := option.Tags
+		if option.TagFunc != nil {
+			tags = append(append([]string{}, tags...), option.TagFunc(r)...)
+		}
+		_ = option.Store.Set(r.Context(), key, entry, option.TTL, tags)
+		r.Response.Header().Set("X-Cache", "MISS")
+		writeCacheEntry(r, entry)
+	}
+}
+
+// writeCacheEntry 输出缓存内容，客户端ETag一致时返回304
+func writeCacheEntry(r *ghttp.Request, entry *CacheEntry) {
+	r.Response.Header().Set("ETag", entry.ETag)
+	r.Response.ClearBuffer()
+	if r.Header.Get("If-None-Match") == entry.ETag {
+		r.Response.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if entry.ContentType != "" {
+		r.Response.Header().Set("Content-Type", entry.ContentType)
+	}
+	r.Response.WriteHeader(entry.Status)
+	r.Response.Write(entry.Body)
+}
@@ -6,25 +6,24 @@ import (
 	"github.com/gogf/gf/v2/database/gdb"
 	"github.com/gogf/gf/v2/encoding/gyaml"
 	"github.com/gogf/gf/v2/frame/g"
-	"github.com/gogf/gf/v2/os/gcfg"
 	"github.com/gogf/gf/v2/os/gctx"
 	"github.com/gogf/gf/v2/os/gfile"
-	"github.com/gogf/gf/v2/os/glog"
 	"github.com/gogf/gf/v2/util/gconv"
 )
 
 type Config struct {
-	Server             ServiceConfig   `yaml:"server"`
-	Database           *DatabaseConfig `yaml:"database"`
-	SkipUrl            string          `yaml:"skipUrl"`
-	OpenAPITitle       string          `yaml:"openAPITitle"`
-	OpenAPIDescription string          `yaml:"openAPIDescription"`
-	OpenAPIUrl         string          `yaml:"openAPIUrl"`
-	OpenAPIName        string          `yaml:"openAPIName"`
-	DoMain             []string        `yaml:"doMain"`
-	OpenAPIVersion     string          `yaml:"openAPIVersion"`
-	Logger             LoggerConfig    `yaml:"logger"`
-	Dns                string          `yaml:"dns"`
+	Server             ServiceConfig     `yaml:"server"`
+	Database           *DatabaseConfig   `yaml:"database"`
+	SkipUrl            string            `yaml:"skipUrl"`
+	OpenAPITitle       string            `yaml:"openAPITitle"`
+	OpenAPIDescription string            `yaml:"openAPIDescription"`
+	OpenAPIUrl         string            `yaml:"openAPIUrl"`
+	OpenAPIName        string            `yaml:"openAPIName"`
+	DoMain             []string          `yaml:"doMain"`
+	OpenAPIVersion     string            `yaml:"openAPIVersion"`
+	Logger             LoggerConfig      `yaml:"logger"`
+	Dns                string            `yaml:"dns"`
+	Maintenance        MaintenanceConfig `yaml:"maintenance"`
 }
 
 type ServiceConfig struct {
@@ -44,7 +43,15 @@ type ServiceDefault struct {
 }
 
 type DatabaseConfig struct {
-	Default DatabaseDefault `yaml:"default"`
+	Default DatabaseDefault           `yaml:"default"`
+	Groups  map[string]*DatabaseGroup `yaml:"groups,omitempty"` // 数据库分组，如报表从库、其他业务库，与default同名时覆盖default
+}
+
+// DatabaseGroup 数据库分组，包含一个主库和多个从库
+// 配置从库后查询默认走从库，写入走主库
+type DatabaseGroup struct {
+	Master DatabaseDefault   `yaml:"master" json:"master"`
+	Slaves []DatabaseDefault `yaml:"slaves,omitempty" json:"slaves"`
 }
 
 type DatabaseDefault struct {
@@ -60,6 +67,52 @@ type DatabaseDefault struct {
 	Charset   string `yaml:"charset" json:"charset"`
 	CreatedAt string `yaml:"createdAt" json:"createdAt"`
 	UpdatedAt string `yaml:"updatedAt" json:"updatedAt"`
+	Extra     string `yaml:"extra,omitempty" dc:"驱动附加参数，如sqlite的journal_mode=WAL" json:"extra"`
+	Prefix    string `yaml:"prefix,omitempty" json:"prefix"`
+	Weight    int    `yaml:"weight,omitempty" dc:"从库负载均衡权重" json:"weight"`
+}
+
+// node 转换为gdb配置节点
+func (d DatabaseDefault) node(role gdb.Role) gdb.ConfigNode {
+	return gdb.ConfigNode{
+		Host:      d.Host,
+		Port:      d.Port,
+		User:      d.User,
+		Pass:      d.Pass,
+		Name:      d.Name,
+		Type:      d.Type,
+		Link:      d.Link,
+		Extra:     d.Extra,
+		Role:      role,
+		Debug:     d.Debug,
+		Prefix:    d.Prefix,
+		Weight:    d.Weight,
+		Charset:   d.Charset,
+		Timezone:  d.Timezone,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+	}
+}
+
+// Register 将default与所有分组注册到gdb，之后通过g.DB(分组名)或dao的Group使用
+func (d *DatabaseConfig) Register() error {
+	config := gdb.Config{
+		gdb.DefaultGroupName: gdb.ConfigGroup{d.Default.node(gdb.RoleMaster)},
+	}
+	for name, group := range d.Groups {
+		if group == nil {
+			continue
+		}
+		nodes := gdb.ConfigGroup{group.Master.node(gdb.RoleMaster)}
+		for _, slave := range group.Slaves {
+			if slave.Weight <= 0 {
+				slave.Weight = 1
+			}
+			nodes = append(nodes, slave.node(gdb.RoleSlave))
+		}
+		config[name] = nodes
+	}
+	return gdb.SetConfig(config)
 }
 
 type LoggerConfig struct {
@@ -95,7 +148,7 @@ var DefaultConfig = Config{
 	OpenAPIName:        "",
 	DoMain:             []string{"localhost", "127.0.0.1"},
 	OpenAPIVersion:     "v1.0",
-	Dns:                "root:123456@tcp(127.0.0.1:3306)/test",
+	Dns:                "root:${env:DB_PASS:-}@tcp(127.0.0.1:3306)/database",
 	Logger: LoggerConfig{
 		Path:              "./log/",
 		File:              "access-{Ymd}.log",
@@ -107,14 +160,41 @@ var DefaultConfig = Config{
 		RotateSize:        "1M",
 		RotateBackupLimit: 10,
 	},
+	Maintenance: MaintenanceConfig{
+		Mode:           MaintenanceOff,
+		Message:        "系统维护中，请稍后再试",
+		RetryAfter:     300,
+		AllowIps:       []string{"127.0.0.1"},
+		TrustedProxies: []string{},
+		AdminTokens:    []string{},
+		FlagFile:       "./resource/maintenance.flag",
+		SkipUrl:        []string{},
+	},
 }
 
-func DefaultConfigInit() {
-	database := &DatabaseConfig{Default: DatabaseDefault{
+// DefaultDatabase 默认数据库配置
+/*
+ * @param dbType string 数据库类型 mysql/sqlite
+ * @return *DatabaseConfig 数据库配置，mysql密码通过环境变量DB_PASS读取，sqlite数据库文件位于resource/data
+ */
+func DefaultDatabase(dbType string) *DatabaseConfig {
+	if dbType == "sqlite" {
+		return &DatabaseConfig{Default: DatabaseDefault{
+			Name:      "./resource/data/app.db",
+			Type:      "sqlite",
+			Extra:     "journal_mode=WAL&foreign_keys=1&busy_timeout=5000",
+			Timezone:  "Local",
+			Debug:     true,
+			Charset:   "utf8",
+			CreatedAt: "create_time",
+			UpdatedAt: "update_time",
+		}}
+	}
+	return &DatabaseConfig{Default: DatabaseDefault{
 		Host:      "127.0.0.1",
 		Port:      "3306",
 		User:      "root",
-		Pass:      "123456",
+		Pass:      "${env:DB_PASS:-}",
 		Name:      "database",
 		Type:      "mysql",
 		Timezone:  "Local",
@@ -123,56 +203,115 @@ func DefaultConfigInit() {
 		CreatedAt: "create_time",
 		UpdatedAt: "update_time",
 	}}
-	DefaultConfig.Database = database
-	yaml, err := gyaml.Encode(DefaultConfig)
+}
+
+// InitResourceDirs 创建resource目录结构与日志目录
+/*
+ * @param root string 项目根目录
+ */
+func InitResourceDirs(root string) error {
+	dirs := []string{
+		gfile.Join(root, "resource"),
+		gfile.Join(root, "resource", "template"),
+		gfile.Join(root, "resource", "scripts"),
+		gfile.Join(root, "resource", "data"),
+		gfile.Join(root, "resource", "public", "html"),
+		gfile.Join(root, "resource", "public", "resource", "css"),
+		gfile.Join(root, "resource", "public", "resource", "image"),
+		gfile.Join(root, "resource", "public", "resource", "js"),
+		gfile.Join(root, DefaultConfig.Server.Default.LogPath),
+		gfile.Join(root, DefaultConfig.Logger.Path),
+	}
+	for _, dir := range dirs {
+		if gfile.IsDir(dir) {
+			continue
+		}
+		if err := gfile.Mkdir(dir); err != nil {
+			return fmt.Errorf("创建目录%s失败: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// WriteDefaultConfig 写入默认配置文件
+/*
+ * @param path string 配置文件路径
+ * @param database *DatabaseConfig 数据库配置
+ * @param force bool 文件已存在时是否覆盖
+ * @return bool 是否写入了文件
+ */
+func WriteDefaultConfig(path string, database *DatabaseConfig, force bool) (bool, error) {
+	if gfile.IsFile(path) && !force {
+		return false, nil
+	}
+	config := DefaultConfig
+	config.Database = database
+	if database != nil && database.Default.Type != "mysql" {
+		// dns仅用于mysql的gorm迁移
+		config.Dns = ""
+	}
+	yaml, err := gyaml.Encode(config)
 	if err != nil {
-		g.Log().Error(gctx.New(), "转换yaml失败", err)
+		return false, fmt.Errorf("转换yaml失败: %w", err)
+	}
+	if err = gfile.PutContents(path, gconv.String(yaml)); err != nil {
+		return false, fmt.Errorf("写入配置文件失败: %w", err)
 	}
+	return true, nil
+}
 
-	if !gfile.IsDir(uploadPath) {
-		_ = gfile.Mkdir(uploadPath)
-		_ = gfile.Mkdir(gfile.Join(gfile.Pwd(), "resource", "template"))
-		_ = gfile.Mkdir(gfile.Join(gfile.Pwd(), "resource", "scripts"))
-		_ = gfile.Mkdir(gfile.Join(gfile.Pwd(), "resource", "public", "html"))
-		_ = gfile.Mkdir(gfile.Join(gfile.Pwd(), "resource", "public", "resource", "css"))
-		_ = gfile.Mkdir(gfile.Join(gfile.Pwd(), "resource", "public", "resource", "image"))
-		_ = gfile.Mkdir(gfile.Join(gfile.Pwd(), "resource", "public", "resource", "js"))
+// DefaultConfigInit 创建默认配置文件（已存在时不覆盖）并加载配置
+/*
+ * @return error 配置文件不合法时返回原因，由调用方决定是否退出
+ */
+func DefaultConfigInit() error {
+	DefaultConfig.Database = DefaultDatabase("mysql")
+	if err := InitResourceDirs(gfile.Pwd()); err != nil {
+		g.Log().Error(gctx.New(), "创建目录失败", err)
 	}
 	g.Log().Info(gctx.New(), "正在检查配置文件", gfile.IsFile(ConfigPath))
-	if !gfile.IsFile(ConfigPath) {
-		g.Log().Info(gctx.New(), "正在创建配置文件", ConfigPath)
-		_, _ = gfile.Create(ConfigPath)
-		g.Log().Info(gctx.New(), "正在写入配置文件", ConfigPath)
-		_ = gfile.PutContents(ConfigPath, gconv.String(yaml))
-		g.Log().Info(gctx.New(), "配置文件创建成功！")
-	} else {
-		gcfg.Instance().GetAdapter().(*gcfg.AdapterFile).SetFileName(ConfigPath)
+	written, err := WriteDefaultConfig(ConfigPath, DefaultConfig.Database, false)
+	if err != nil {
+		g.Log().Error(gctx.New(), "创建配置文件失败", err)
+	} else if written {
+		g.Log().Info(gctx.New(), "配置文件创建成功！数据库密码请通过环境变量DB_PASS设置")
+	}
+	// 解析引用与环境变量覆盖并校验
+	if _, err = LoadConfig(ConfigPath); err != nil {
+		return fmt.Errorf("加载配置文件失败: %w", err)
 	}
+	return nil
 }
 
 // DefaultSqliteConfigInit 创建默认的sqlite数据库配置 不会再生成配置文件
+// 默认开启WAL、外键约束并设置5秒忙等待
 // @param path sqlite数据库路径
-// @param autoTime 自动时间字段[]string{"create_time","update_time"}
+// @param autoTime 自动时间字段[]string{"create_time","update_time"}，为空或不足时使用默认值
 // @param debug 数据库调试模式
 // @param prefix 表前缀可空
 func DefaultSqliteConfigInit(path string, autoTime []string, debug bool, prefix ...string) {
-	glog.Info(gctx.New(), "正在检查文件夹", gfile.IsFile(uploadPath))
-	if !gfile.IsDir(uploadPath) {
-		_ = gfile.Mkdir(uploadPath)
-		_ = gfile.Mkdir(gfile.Join(gfile.Pwd(), "resource", "template"))
-		_ = gfile.Mkdir(gfile.Join(gfile.Pwd(), "resource", "scripts"))
-		_ = gfile.Mkdir(gfile.Join(gfile.Pwd(), "resource", "public", "html"))
-		_ = gfile.Mkdir(gfile.Join(gfile.Pwd(), "resource", "public", "resource", "css"))
-		_ = gfile.Mkdir(gfile.Join(gfile.Pwd(), "resource", "public", "resource", "image"))
-		_ = gfile.Mkdir(gfile.Join(gfile.Pwd(), "resource", "public", "resource", "js"))
+	if err := InitResourceDirs(gfile.Pwd()); err != nil {
+		g.Log().Error(gctx.New(), "创建目录失败", err)
+	}
+	if dir := gfile.Dir(path); dir != "" && !gfile.IsDir(dir) {
+		_ = gfile.Mkdir(dir)
 	}
 	g.Log().Info(gctx.New(), "正在设置数据库配置")
+	createdAt, updatedAt := "create_time", "update_time"
+	if len(autoTime) > 0 && autoTime[0] != "" {
+		createdAt = autoTime[0]
+	}
+	if len(autoTime) > 1 && autoTime[1] != "" {
+		updatedAt = autoTime[1]
+	}
 	node := gdb.ConfigNode{
-		Link:      fmt.Sprintf("sqlite::@file(%s)", path),
+		Type:      "sqlite",
+		Name:      path,
+		Extra:     "journal_mode=WAL&foreign_keys=1&busy_timeout=5000",
 		Timezone:  "Local",
 		Charset:   "utf8",
-		CreatedAt: autoTime[0],
-		UpdatedAt: autoTime[1],
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
 		Debug:     debug,
 	}
 	if len(prefix) > 0 {
@@ -0,0 +1,394 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/gogf/gf/v2/database/gdb"
+	"github.com/gogf/gf/v2/encoding/gyaml"
+	"github.com/gogf/gf/v2/os/gcfg"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/gogf/gf/v2/os/gfsnotify"
+	"github.com/gogf/gf/v2/os/glog"
+	"github.com/gogf/gf/v2/text/gstr"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// ConfigEnvPrefix 覆盖配置项的环境变量前缀
+// 例：BASE_SERVER_DEFAULT_ADDRESS 覆盖 server.default.address，驼峰字段可写作 LOGPATH 或 LOG_PATH
+const ConfigEnvPrefix = "BASE_"
+
+// configRefPattern 匹配 ${env:NAME}、${env:NAME:-默认值}、${file:/run/secrets/x}
+var configRefPattern = regexp.MustCompile(`\$\{(env|file):([^}:]+)(:-([^}]*))?}`)
+
+// configWatched 已监听变更的配置文件
+var configWatched sync.Map
+
+// LoadConfig 加载配置文件：解析引用、应用环境变量覆盖、校验，并设置为全局配置
+/*
+ * 配置文件变更后自动重新加载，重新加载失败时保留原配置并记录错误
+ * @param path ...string 配置文件路径，默认ConfigPath
+ * @return *Config 解析后的配置
+ * @return error 读取、解析或校验失败的原因
+ */
+func LoadConfig(path ...string) (*Config, error) {
+	configPath := ConfigPath
+	if len(path) > 0 && path[0] != "" {
+		configPath = path[0]
+	}
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, loaded := configWatched.LoadOrStore(configPath, true); !loaded {
+		_, err = gfsnotify.Add(configPath, func(event *gfsnotify.Event) {
+			if !event.IsWrite() && !event.IsCreate() && !event.IsRename() {
+				return
+			}
+			if _, err := loadConfig(configPath); err != nil {
+				glog.Error(context.Background(), "重新加载配置文件失败，继续使用原配置:", err)
+			}
+		})
+		if err != nil {
+			glog.Warning(context.Background(), "监听配置文件变更失败:", err)
+		}
+	}
+	return config, nil
+}
+
+// loadConfig 读取并解析配置文件，成功后设置为全局配置
+func loadConfig(configPath string) (*Config, error) {
+	if !gfile.IsFile(configPath) {
+		return nil, fmt.Errorf("配置文件不存在: %s", configPath)
+	}
+	data, err := gyaml.Decode([]byte(gfile.GetContents(configPath)))
+	if err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	if data == nil {
+		data = make(map[string]any)
+	}
+	if err = ResolveConfigRefs(data); err != nil {
+		return nil, err
+	}
+	applyConfigEnv(data)
+	yaml, err := gyaml.Encode(data)
+	if err != nil {
+		return nil, fmt.Errorf("转换yaml失败: %w", err)
+	}
+	config := new(Config)
+	if err = gyaml.DecodeTo(yaml, config); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	if err = ValidateConfig(config); err != nil {
+		return nil, err
+	}
+	if config.Database != nil && len(config.Database.Groups) > 0 {
+		if err = config.Database.Register(); err != nil {
+			return nil, fmt.Errorf("注册数据库分组失败: %w", err)
+		}
+	}
+	adapter, err := gcfg.NewAdapterContent(string(yaml))
+	if err != nil {
+		return nil, fmt.Errorf("设置配置失败: %w", err)
+	}
+	gcfg.Instance().SetAdapter(adapter)
+	return config, nil
+}
+
+// ResolveConfigRefs 解析已解码配置中字符串值里的 ${env:X} 与 ${file:/path} 引用
+// ${env:X:-默认值} 在环境变量未设置时使用默认值，file引用会去除首尾空白
+// 引用在解码后替换，值中的#、冒号、引号、换行等不会影响yaml结构；
+// 整个值为单个引用时按Config中该项的类型转换，如端口、开关
+func ResolveConfigRefs(data map[string]any) error {
+	samples := make(map[string]any)
+	if content, err := gyaml.Encode(Config{Database: &DatabaseConfig{}}); err == nil {
+		known, _ := gyaml.Decode(content)
+		paths := make(map[string]configEnvPath)
+		collectConfigPaths(known, nil, paths)
+		for _, item := range paths {
+			samples[gstr.Join(item.path, ".")] = item.sample
+		}
+	}
+	var errs []string
+	resolveConfigNode(data, nil, samples, &errs)
+	if len(errs) > 0 {
+		return fmt.Errorf("解析配置引用失败: %s", gstr.Join(errs, "；"))
+	}
+	return nil
+}
+
+// resolveConfigNode 递归解析配置节点中的引用
+func resolveConfigNode(node any, path []string, samples map[string]any, errs *[]string) any {
+	switch value := node.(type) {
+	case map[string]any:
+		for key, child := range value {
+			value[key] = resolveConfigNode(child, append(append([]string{}, path...), key), samples, errs)
+		}
+	case []any:
+		for i, child := range value {
+			value[i] = resolveConfigNode(child, path, nil, errs)
+		}
+	case string:
+		if !configRefPattern.MatchString(value) {
+			return value
+		}
+		whole := configRefPattern.FindString(value) == value
+		resolved := configRefPattern.ReplaceAllStringFunc(value, func(ref string) string {
+			return resolveConfigRef(ref, errs)
+		})
+		if sample, ok := samples[gstr.Join(path, ".")]; ok && whole {
+			return configValue(sample, resolved)
+		}
+		return resolved
+	}
+	return node
+}
+
+// resolveConfigRef 解析单个引用，无法解析时记录错误并保留原文
+func resolveConfigRef(ref string, errs *[]string) string {
+	match := configRefPattern.FindStringSubmatch(ref)
+	var (
+		kind       = match[1]
+		name       = gstr.Trim(match[2])
+		hasDefault = match[3] != ""
+	)
+	switch kind {
+	case "env":
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		if hasDefault {
+			return match[4]
+		}
+		*errs = append(*errs, fmt.Sprintf("环境变量%s未设置", name))
+	case "file":
+		if gfile.IsFile(name) {
+			return gstr.Trim(gfile.GetContents(name))
+		}
+		if hasDefault {
+			return match[4]
+		}
+		*errs = append(*errs, fmt.Sprintf("密钥文件%s不存在", name))
+	}
+	return ref
+}
+
+// configEnvPath 可被环境变量覆盖的配置项
+type configEnvPath struct {
+	path   []string // 配置项路径
+	sample any      // 原值，用于确定环境变量的转换类型
+}
+
+// applyConfigEnv 使用BASE_开头的环境变量覆盖配置项
+// 可覆盖的配置项包括配置文件中已有的键与Config结构体中定义的键
+func applyConfigEnv(data map[string]any) {
+	paths := make(map[string]configEnvPath)
+	if content, err := gyaml.Encode(Config{Database: &DatabaseConfig{}}); err == nil {
+		known, _ := gyaml.Decode(content)
+		collectConfigPaths(known, nil, paths)
+	}
+	collectConfigPaths(data, nil, paths)
+	names := make([]string, 0, len(paths))
+	for name := range paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		setConfigPath(data, paths[name], value)
+	}
+}
+
+// collectConfigPaths 收集所有叶子节点的环境变量名与路径
+func collectConfigPaths(data map[string]any, prefix []string, paths map[string]configEnvPath) {
+	for key, value := range data {
+		path := append(append([]string{}, prefix...), key)
+		if child, ok := value.(map[string]any); ok && len(child) > 0 {
+			collectConfigPaths(child, path, paths)
+			continue
+		}
+		var plain, snake []string
+		for _, item := range path {
+			plain = append(plain, gstr.ToUpper(item))
+			snake = append(snake, gstr.CaseSnakeScreaming(item))
+		}
+		item := configEnvPath{path: path, sample: value}
+		paths[ConfigEnvPrefix+gstr.Join(plain, "_")] = item
+		paths[ConfigEnvPrefix+gstr.Join(snake, "_")] = item
+	}
+}
+
+// setConfigPath 按路径设置配置值，根据原值类型转换环境变量
+func setConfigPath(data map[string]any, item configEnvPath, value string) {
+	node := data
+	for _, key := range item.path[:len(item.path)-1] {
+		child, ok := node[key].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			node[key] = child
+		}
+		node = child
+	}
+	node[item.path[len(item.path)-1]] = configValue(item.sample, value)
+}
+
+// configValue 根据原值类型转换字符串配置值，列表按逗号分隔
+func configValue(sample any, value string) any {
+	switch sample.(type) {
+	case bool:
+		return gconv.Bool(value)
+	case int, int64, uint64:
+		return gconv.Int64(value)
+	case float64:
+		return gconv.Float64(value)
+	case []any:
+		items := gstr.SplitAndTrim(value, ",")
+		list := make([]any, len(items))
+		for i, v := range items {
+			list[i] = v
+		}
+		return list
+	default:
+		return value
+	}
+}
+
+// ValidateConfig 校验配置，返回所有不合法的配置项
+func ValidateConfig(config *Config) error {
+	var errs []string
+	addErr := func(format string, args ...any) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+
+	// 服务配置
+	server := config.Server.Default
+	if server.Address == "" {
+		addErr("server.default.address 不能为空")
+	} else if err := validateAddress(server.Address); err != nil {
+		addErr("server.default.address 不合法: %v", err)
+	}
+	if server.LogPath != "" && !gfile.IsDir(server.LogPath) {
+		addErr("server.default.logPath 目录不存在: %s", server.LogPath)
+	}
+
+	// 日志配置
+	if config.Logger.Path != "" && !gfile.IsDir(config.Logger.Path) {
+		addErr("logger.path 目录不存在: %s", config.Logger.Path)
+	}
+	if config.Logger.Level != "" {
+		if err := glog.New().SetLevelStr(config.Logger.Level); err != nil {
+			addErr("logger.level 不合法: %s", config.Logger.Level)
+		}
+	}
+	if config.Logger.RotateSize != "" && config.Logger.RotateSize != "0" && gfile.StrToSize(config.Logger.RotateSize) <= 0 {
+		addErr("logger.rotateSize 不合法: %s", config.Logger.RotateSize)
+	}
+
+	// 数据库配置
+	if config.Database != nil {
+		if _, ok := config.Database.Groups[gdb.DefaultGroupName]; !ok {
+			validateDatabaseNode("database.default", config.Database.Default, addErr)
+		}
+		for name, group := range config.Database.Groups {
+			if group == nil {
+				addErr("database.groups.%s 不能为空", name)
+				continue
+			}
+			validateDatabaseNode("database.groups."+name+".master", group.Master, addErr)
+			for i, slave := range group.Slaves {
+				validateDatabaseNode(fmt.Sprintf("database.groups.%s.slaves[%d]", name, i), slave, addErr)
+				if slave.Weight < 0 {
+					addErr("database.groups.%s.slaves[%d].weight 不能小于0", name, i)
+				}
+			}
+		}
+	}
+
+	// 跨域配置
+	for i, domain := range config.DoMain {
+		if gstr.Trim(domain) == "" {
+			addErr("doMain[%d] 不能为空", i)
+		}
+	}
+
+	// 维护模式配置
+	switch config.Maintenance.Mode {
+	case "", MaintenanceOff, MaintenanceReadOnly, MaintenanceFull:
+	default:
+		addErr("maintenance.mode 只能为off、readonly或full: %s", config.Maintenance.Mode)
+	}
+	for i, ip := range config.Maintenance.AllowIps {
+		if _, _, err := net.ParseCIDR(ip); err != nil && net.ParseIP(ip) == nil {
+			addErr("maintenance.allowIps[%d] 不是合法的IP或CIDR: %s", i, ip)
+		}
+	}
+	for i, ip := range config.Maintenance.TrustedProxies {
+		if _, _, err := net.ParseCIDR(ip); err != nil && net.ParseIP(ip) == nil {
+			addErr("maintenance.trustedProxies[%d] 不是合法的IP或CIDR: %s", i, ip)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New("配置校验失败:\n  " + gstr.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+// validateDatabaseNode 校验数据库节点配置
+func validateDatabaseNode(name string, node DatabaseDefault, addErr func(format string, args ...any)) {
+	if node.Link != "" {
+		return
+	}
+	if node.Type == "" {
+		addErr("%s.type 不能为空", name)
+		return
+	}
+	switch node.Type {
+	case "mysql", "pgsql", "sqlite":
+	default:
+		addErr("%s.type 只能为mysql、pgsql或sqlite: %s", name, node.Type)
+		return
+	}
+	if node.Type == "sqlite" {
+		if node.Name == "" {
+			addErr("%s.name 不能为空（sqlite数据库文件路径）", name)
+		}
+		return
+	}
+	if node.Host == "" {
+		addErr("%s.host 不能为空", name)
+	}
+	if port := gconv.Int(node.Port); node.Port != "" && (port <= 0 || port > 65535) {
+		addErr("%s.port 不合法: %s", name, node.Port)
+	}
+	if node.User == "" {
+		addErr("%s.user 不能为空", name)
+	}
+	if node.Name == "" {
+		addErr("%s.name 不能为空", name)
+	}
+}
+
+// validateAddress 校验监听地址，格式为host:port或:port
+func validateAddress(address string) error {
+	for _, item := range gstr.SplitAndTrim(address, ",") {
+		_, port, err := net.SplitHostPort(item)
+		if err != nil {
+			return err
+		}
+		if p := gconv.Int(port); port != "0" && (p <= 0 || p > 65535) {
+			return fmt.Errorf("端口不合法: %s", port)
+		}
+	}
+	return nil
+}
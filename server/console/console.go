@@ -0,0 +1,284 @@
+package console
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/black1552/base-common/baseGrpc"
+	"github.com/black1552/base-common/mqtt/client"
+	"github.com/black1552/base-common/server"
+	"github.com/black1552/base-common/server/ws"
+	"github.com/black1552/base-common/task"
+	"github.com/black1552/base-common/tcp"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// Console 运行时管理控制台，可查看和管理在线连接、任务与服务
+// 未设置的组件对应接口返回"未启用"
+type Console struct {
+	Ws   *ws.Manager
+	Tcp  *tcp.TCPServer
+	Mqtt *client.Client
+	Grpc *baseGrpc.SGrpc
+}
+
+// TcpConnInfo TCP连接信息
+type TcpConnInfo struct {
+	Id        string    `json:"id" dc:"连接ID"`
+	Address   string    `json:"address" dc:"客户端地址"`
+	IsActive  bool      `json:"isActive" dc:"是否活跃"`
+	LastUsed  time.Time `json:"lastUsed" dc:"最后使用时间"`
+	CreatedAt time.Time `json:"createdAt" dc:"创建时间"`
+}
+
+// MqttSubscription MQTT订阅信息
+type MqttSubscription struct {
+	Topic string `json:"topic" dc:"主题"`
+	Qos   byte   `json:"qos" dc:"QoS"`
+}
+
+// MqttState MQTT订阅状态
+type MqttState struct {
+	Connected     bool               `json:"connected" dc:"是否已连接"`
+	Subscriptions []MqttSubscription `json:"subscriptions" dc:"订阅列表"`
+}
+
+// TaskInfo 任务信息
+type TaskInfo struct {
+	Token string `json:"token" dc:"任务标识"`
+	task.Task
+}
+
+// GrpcService gRPC服务信息
+type GrpcService struct {
+	Name      string   `json:"name" dc:"服务名"`
+	Version   string   `json:"version" dc:"版本"`
+	Endpoints []string `json:"endpoints" dc:"服务地址"`
+}
+
+var errDisabled = errors.New("该组件未启用")
+
+// Mount 挂载管理路由，路由组会自动添加AuthAdmin鉴权
+/*
+ * 例：s.Group("/admin/console", console.Mount)
+ * @param group *ghttp.RouterGroup 路由组
+ */
+func (c *Console) Mount(group *ghttp.RouterGroup) {
+	group.Middleware(server.AuthAdmin)
+	group.GET("/ws", c.wsList)
+	group.GET("/ws/{connId}", c.wsInfo)
+	group.POST("/ws/{connId}/kick", c.wsKick)
+	group.GET("/tcp", c.tcpList)
+	group.POST("/tcp/{connId}/kick", c.tcpKick)
+	group.GET("/mqtt", c.mqttState)
+	group.GET("/task", c.taskList)
+	group.GET("/task/{token}", c.taskInfo)
+	group.DELETE("/task/{token}", c.taskRemove)
+	group.GET("/grpc", c.grpcList)
+}
+
+// page 分页输出列表
+func page[T any](r *ghttp.Request, items []T) {
+	var (
+		pageNum = r.Get("page", 1).Int()
+		limit   = r.Get("limit", 20).Int()
+		total   = len(items)
+	)
+	if pageNum < 1 {
+		pageNum = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	start := (pageNum - 1) * limit
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if end > total {
+		end = total
+	}
+	server.Success(r.Context()).SetMsg("操作成功").SetData(server.SetPage(pageNum, limit, total, items[start:end])).End()
+}
+
+// fail 输出错误信息
+func fail(r *ghttp.Request, err error) {
+	server.Error(r.Context()).SetMsg(err.Error()).End()
+}
+
+// wsList WebSocket连接列表
+func (c *Console) wsList(r *ghttp.Request) {
+	if c.Ws == nil {
+		fail(r, errDisabled)
+		return
+	}
+	page(r, c.Ws.Snapshot())
+}
+
+// wsSessions 按参数获取连接，传sessionId时只取该会话，否则取connId的所有会话
+func (c *Console) wsSessions(r *ghttp.Request) []*ws.Connection {
+	if sessionID := r.Get("sessionId").String(); sessionID != "" {
+		if conn := c.Ws.GetSession(sessionID); conn != nil {
+			return []*ws.Connection{conn}
+		}
+		return nil
+	}
+	return c.Ws.GetConns(r.Get("connId").String())
+}
+
+// wsInfo WebSocket连接详情，返回connId的所有会话
+func (c *Console) wsInfo(r *ghttp.Request) {
+	if c.Ws == nil {
+		fail(r, errDisabled)
+		return
+	}
+	conns := c.wsSessions(r)
+	if len(conns) == 0 {
+		fail(r, errors.New("连接不存在"))
+		return
+	}
+	infos := make([]ws.ConnInfo, 0, len(conns))
+	for _, conn := range conns {
+		infos = append(infos, conn.Info())
+	}
+	server.Success(r.Context()).SetMsg("操作成功").SetData(infos).End()
+}
+
+// wsKick 踢出WebSocket连接，传sessionId时只踢出该会话，否则踢出connId的所有会话
+func (c *Console) wsKick(r *ghttp.Request) {
+	if c.Ws == nil {
+		fail(r, errDisabled)
+		return
+	}
+	conns := c.wsSessions(r)
+	if len(conns) == 0 {
+		fail(r, errors.New("连接不存在"))
+		return
+	}
+	for _, conn := range conns {
+		conn.Close(errors.New("管理员踢出"))
+	}
+	server.Success(r.Context()).SetMsg("操作成功").End()
+}
+
+// tcpList TCP连接列表
+func (c *Console) tcpList(r *ghttp.Request) {
+	if c.Tcp == nil {
+		fail(r, errDisabled)
+		return
+	}
+	conns := c.Tcp.Connection.GetAll()
+	items := make([]TcpConnInfo, 0, len(conns))
+	for _, conn := range conns {
+		conn.Mutex.RLock()
+		items = append(items, TcpConnInfo{
+			Id:        conn.Id,
+			Address:   conn.Address,
+			IsActive:  conn.IsActive,
+			LastUsed:  conn.LastUsed,
+			CreatedAt: conn.CreatedAt,
+		})
+		conn.Mutex.RUnlock()
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	page(r, items)
+}
+
+// tcpKick 踢出TCP连接
+func (c *Console) tcpKick(r *ghttp.Request) {
+	if c.Tcp == nil {
+		fail(r, errDisabled)
+		return
+	}
+	if err := c.Tcp.Kick(r.Get("connId").String()); err != nil {
+		fail(r, err)
+		return
+	}
+	server.Success(r.Context()).SetMsg("操作成功").End()
+}
+
+// mqttState MQTT订阅状态
+func (c *Console) mqttState(r *ghttp.Request) {
+	if c.Mqtt == nil {
+		fail(r, errDisabled)
+		return
+	}
+	state := MqttState{
+		Connected:     c.Mqtt.IsConnected(),
+		Subscriptions: make([]MqttSubscription, 0),
+	}
+	for topic, qos := range c.Mqtt.Subscriptions() {
+		state.Subscriptions = append(state.Subscriptions, MqttSubscription{Topic: topic, Qos: qos})
+	}
+	sort.Slice(state.Subscriptions, func(i, j int) bool {
+		return state.Subscriptions[i].Topic < state.Subscriptions[j].Topic
+	})
+	server.Success(r.Context()).SetMsg("操作成功").SetData(state).End()
+}
+
+// taskList 任务列表
+func (c *Console) taskList(r *ghttp.Request) {
+	tasks := task.Manager.ListTasks()
+	items := make([]TaskInfo, 0, len(tasks))
+	for token, t := range tasks {
+		items = append(items, TaskInfo{Token: token, Task: t})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Token < items[j].Token
+	})
+	page(r, items)
+}
+
+// taskInfo 任务详情
+func (c *Console) taskInfo(r *ghttp.Request) {
+	token := r.Get("token").String()
+	t, ok := task.Manager.SnapshotTask(token)
+	if !ok {
+		fail(r, errors.New("任务不存在"))
+		return
+	}
+	server.Success(r.Context()).SetMsg("操作成功").SetData(TaskInfo{Token: token, Task: t}).End()
+}
+
+// taskRemove 移除任务
+func (c *Console) taskRemove(r *ghttp.Request) {
+	token := r.Get("token").String()
+	if _, ok := task.Manager.GetTask(token); !ok {
+		fail(r, errors.New("任务不存在"))
+		return
+	}
+	task.Manager.RemoveTask(token)
+	server.Success(r.Context()).SetMsg("操作成功").End()
+}
+
+// grpcList 已注册的gRPC服务
+func (c *Console) grpcList(r *ghttp.Request) {
+	if c.Grpc == nil {
+		fail(r, errDisabled)
+		return
+	}
+	services, err := c.Grpc.GetServers(r.Context())
+	if err != nil {
+		fail(r, err)
+		return
+	}
+	items := make([]GrpcService, 0, len(services))
+	for _, service := range services {
+		endpoints := make([]string, 0)
+		for _, endpoint := range service.GetEndpoints() {
+			endpoints = append(endpoints, endpoint.String())
+		}
+		items = append(items, GrpcService{
+			Name:      service.GetName(),
+			Version:   service.GetVersion(),
+			Endpoints: endpoints,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	page(r, items)
+}
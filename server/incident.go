@@ -0,0 +1,38 @@
+package server
+
+import (
+	"github.com/gogf/gf/v2/errors/gcode"
+	"github.com/gogf/gf/v2/errors/gerror"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/grand"
+)
+
+// IncidentHeader 返回事件编号的响应头
+const IncidentHeader = "X-Incident-Id"
+
+// incidentChars 事件编号字符，去除了0、O、1、I等易混淆字符
+const incidentChars = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// IncidentData 发生异常时返回的数据
+type IncidentData struct {
+	IncidentId string `json:"incidentId" dc:"事件编号，反馈问题时提供给客服用于查找日志"`
+}
+
+// IsPanic 判断请求错误是否由panic产生
+func IsPanic(err error) bool {
+	return err != nil && gerror.Code(err) == gcode.CodeInternalPanic
+}
+
+// NewIncidentId 生成8位事件编号
+func NewIncidentId() string {
+	return grand.Str(incidentChars, 8)
+}
+
+// recordIncident 记录panic的堆栈并返回事件编号，日志中可按"incident=编号"查找
+func recordIncident(r *ghttp.Request, err error) string {
+	id := NewIncidentId()
+	g.Log().Errorf(r.Context(), "incident=%s %s %s panic: %+v", id, r.Method, r.URL.Path, err)
+	r.Response.Header().Set(IncidentHeader, id)
+	return id
+}
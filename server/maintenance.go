@@ -0,0 +1,276 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gcfg"
+	"github.com/gogf/gf/v2/os/gfile"
+	"github.com/gogf/gf/v2/text/gstr"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// MaintenanceMode 维护模式
+type MaintenanceMode string
+
+const (
+	MaintenanceOff      MaintenanceMode = "off"      // 正常服务
+	MaintenanceReadOnly MaintenanceMode = "readonly" // 只读，拦截POST/PUT/PATCH/DELETE
+	MaintenanceFull     MaintenanceMode = "full"     // 完全不可用
+)
+
+// MaintenanceTokenHeader 维护期间管理员绕过维护模式的请求头
+const MaintenanceTokenHeader = "X-Maintenance-Token"
+
+// maintenanceFlagInterval 标记文件状态的缓存时间
+const maintenanceFlagInterval = time.Second
+
+// MaintenanceConfig 维护模式配置
+type MaintenanceConfig struct {
+	Mode           MaintenanceMode `yaml:"mode" json:"mode"`                     // 维护模式 off/readonly/full
+	Message        string          `yaml:"message" json:"message"`               // 返回给客户端的提示信息
+	RetryAfter     int             `yaml:"retryAfter" json:"retryAfter"`         // 建议客户端重试的秒数
+	AllowIps       []string        `yaml:"allowIps" json:"allowIps"`             // 可绕过维护的IP，支持CIDR，按连接的对端IP判断
+	TrustedProxies []string        `yaml:"trustedProxies" json:"trustedProxies"` // 可信代理IP，支持CIDR，对端为可信代理时才按X-Forwarded-For判断客户端IP
+	AdminTokens    []string        `yaml:"adminTokens" json:"adminTokens"`       // 可绕过维护的管理员令牌，通过X-Maintenance-Token请求头传递
+	FlagFile       string          `yaml:"flagFile" json:"flagFile"`             // 标记文件，文件存在即开启维护，文件内容为readonly时只读
+	SkipUrl        []string        `yaml:"skipUrl" json:"skipUrl"`               // 维护期间仍可访问的路由，支持/admin/*前缀匹配
+}
+
+// MaintenanceStatus 维护状态
+type MaintenanceStatus struct {
+	Mode       MaintenanceMode `json:"mode" dc:"当前生效的维护模式"`
+	Message    string          `json:"message" dc:"提示信息"`
+	RetryAfter int             `json:"retryAfter" dc:"建议重试秒数"`
+	FromFile   bool            `json:"fromFile" dc:"是否由标记文件开启"`
+}
+
+// sMaintenance 维护模式开关
+type sMaintenance struct {
+	config MaintenanceConfig
+	mutex  sync.RWMutex
+	flag   maintenanceFlag // 标记文件状态缓存
+}
+
+// maintenanceFlag 标记文件状态缓存，避免每个请求都读取文件
+type maintenanceFlag struct {
+	file      string
+	exists    bool
+	readOnly  bool
+	checkTime time.Time
+	mutex     sync.Mutex
+}
+
+var Maintenance = &sMaintenance{
+	config: MaintenanceConfig{
+		Mode:       MaintenanceOff,
+		Message:    "系统维护中，请稍后再试",
+		RetryAfter: 300,
+	},
+}
+
+// Init 使用配置初始化维护模式
+func (m *sMaintenance) Init(config MaintenanceConfig) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if config.Mode == "" {
+		config.Mode = MaintenanceOff
+	}
+	if config.Message == "" {
+		config.Message = m.config.Message
+	}
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = m.config.RetryAfter
+	}
+	m.config = config
+}
+
+// InitFromConfig 从配置文件的maintenance节点初始化维护模式
+func (m *sMaintenance) InitFromConfig(ctx context.Context) error {
+	cfg, err := gcfg.Instance().Get(ctx, "maintenance", nil)
+	if err != nil {
+		return err
+	}
+	if cfg.IsNil() {
+		return nil
+	}
+	var config MaintenanceConfig
+	if err = cfg.Scan(&config); err != nil {
+		return err
+	}
+	m.Init(config)
+	return nil
+}
+
+// Set 切换维护模式
+/*
+ * @param mode MaintenanceMode 维护模式
+ * @param message string 提示信息，为空时保持原信息
+ * @param retryAfter ...int 建议重试秒数
+ */
+func (m *sMaintenance) Set(mode MaintenanceMode, message string, retryAfter ...int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.config.Mode = mode
+	if message != "" {
+		m.config.Message = message
+	}
+	if len(retryAfter) > 0 && retryAfter[0] > 0 {
+		m.config.RetryAfter = retryAfter[0]
+	}
+}
+
+// Status 获取当前生效的维护状态，标记文件优先于运行时开关
+func (m *sMaintenance) Status() MaintenanceStatus {
+	m.mutex.RLock()
+	config := m.config
+	m.mutex.RUnlock()
+	status := MaintenanceStatus{
+		Mode:       config.Mode,
+		Message:    config.Message,
+		RetryAfter: config.RetryAfter,
+	}
+	if exists, readOnly := m.flag.check(config.FlagFile); exists {
+		status.FromFile = true
+		if readOnly {
+			if status.Mode != MaintenanceFull {
+				status.Mode = MaintenanceReadOnly
+			}
+		} else {
+			status.Mode = MaintenanceFull
+		}
+	}
+	return status
+}
+
+// check 获取标记文件是否存在及是否为只读，结果缓存maintenanceFlagInterval
+func (f *maintenanceFlag) check(file string) (exists bool, readOnly bool) {
+	if file == "" {
+		return false, false
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file != file || time.Since(f.checkTime) >= maintenanceFlagInterval {
+		f.file = file
+		f.checkTime = time.Now()
+		f.exists = gfile.IsFile(file)
+		f.readOnly = f.exists && gstr.Trim(gfile.GetContents(file)) == string(MaintenanceReadOnly)
+	}
+	return f.exists, f.readOnly
+}
+
+// canBypass 判断请求是否可以绕过维护模式
+func (m *sMaintenance) canBypass(r *ghttp.Request) bool {
+	m.mutex.RLock()
+	config := m.config
+	m.mutex.RUnlock()
+	if matchRoutes(config.SkipUrl, r.URL.Path) {
+		return true
+	}
+	if token := r.Header.Get(MaintenanceTokenHeader); token != "" && matchToken(config.AdminTokens, token) {
+		return true
+	}
+	ip := maintenanceClientIp(r, config.TrustedProxies)
+	return ip != nil && matchIps(config.AllowIps, ip)
+}
+
+// maintenanceClientIp 获取客户端IP，对端不是可信代理时不采信X-Forwarded-For，防止伪造
+/*
+ * 对端为可信代理时，从右向左取X-Forwarded-For中第一个不是可信代理的IP
+ */
+func maintenanceClientIp(r *ghttp.Request, trustedProxies []string) net.IP {
+	ip := net.ParseIP(r.GetRemoteIp())
+	if ip == nil || !matchIps(trustedProxies, ip) {
+		return ip
+	}
+	forwarded := gstr.SplitAndTrim(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		forwardedIp := net.ParseIP(forwarded[i])
+		if forwardedIp == nil {
+			return ip
+		}
+		ip = forwardedIp
+		if !matchIps(trustedProxies, ip) {
+			return ip
+		}
+	}
+	return ip
+}
+
+// matchToken 判断令牌是否命中列表，逐个做常量时间比较，避免通过响应耗时猜测令牌
+func matchToken(list []string, token string) bool {
+	matched := false
+	for _, allow := range list {
+		if allow != "" && subtle.ConstantTimeCompare([]byte(allow), []byte(token)) == 1 {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// matchIps 判断IP是否命中列表，支持CIDR
+func matchIps(list []string, ip net.IP) bool {
+	for _, allow := range list {
+		if gstr.Contains(allow, "/") {
+			if _, ipNet, err := net.ParseCIDR(allow); err == nil && ipNet.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if allowIp := net.ParseIP(allow); allowIp != nil && allowIp.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMutatingMethod 是否为写操作请求
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// MiddlewareMaintenance 维护模式中间件，维护期间返回503
+func MiddlewareMaintenance(r *ghttp.Request) {
+	status := Maintenance.Status()
+	blocked := status.Mode == MaintenanceFull ||
+		(status.Mode == MaintenanceReadOnly && isMutatingMethod(r.Method))
+	if !blocked || Maintenance.canBypass(r) {
+		r.Middleware.Next()
+		return
+	}
+	r.Response.Header().Set("Retry-After", gconv.String(status.RetryAfter))
+	r.Response.Status = http.StatusServiceUnavailable
+	r.Response.WriteJsonExit(Json{
+		Code: http.StatusServiceUnavailable,
+		Data: nil,
+		Msg:  status.Message,
+	})
+}
+
+// MaintenanceHandler 维护模式管理接口，GET查询状态，POST切换模式
+// 需挂载在AuthAdmin保护的路由组下，并将路由加入SkipUrl
+// POST参数：mode(off/readonly/full)、message、retryAfter
+func MaintenanceHandler(r *ghttp.Request) {
+	if r.Method == http.MethodPost {
+		mode := MaintenanceMode(r.Get("mode").String())
+		switch mode {
+		case MaintenanceOff, MaintenanceReadOnly, MaintenanceFull:
+		default:
+			Error(r.Context()).SetMsg("维护模式只能为off、readonly或full").End()
+			return
+		}
+		Maintenance.Set(mode, r.Get("message").String(), r.Get("retryAfter").Int())
+		g.Log().Info(r.Context(), "维护模式已切换为", mode, "操作IP", r.GetClientIp())
+	}
+	Success(r.Context()).SetData(Maintenance.Status()).End()
+}
@@ -0,0 +1,136 @@
+package servertest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/black1552/base-common/server"
+	"github.com/black1552/base-common/utils"
+	"github.com/gogf/gf/v2/net/gclient"
+)
+
+// Client 测试客户端，请求体使用json，会自动保存服务端下发的会话
+type Client struct {
+	*gclient.Client
+	t         testing.TB
+	sessionId string
+}
+
+// Response 解析后的响应，Data为Json.Data按T解析的结果
+type Response[T any] struct {
+	t      testing.TB
+	Status int         // http状态码
+	Header http.Header // 响应头
+	Body   []byte      // 原始响应体
+	Code   int         // Json.Code
+	Data   T           // Json.Data
+	Msg    string      // Json.Msg
+}
+
+// envelope 与server.Json结构一致，Data按类型解析
+type envelope[T any] struct {
+	Code int    `json:"code"`
+	Data T      `json:"data"`
+	Msg  string `json:"msg"`
+}
+
+// newClient 创建客户端
+func newClient(t testing.TB, url string) *Client {
+	c := &Client{Client: gclient.New().ContentJson().Prefix(url), t: t}
+	c.Use(c.keepSession)
+	return c
+}
+
+// keepSession 保存响应中的会话id，后续请求携带
+func (c *Client) keepSession(client *gclient.Client, r *http.Request) (*gclient.Response, error) {
+	resp, err := client.Next(r)
+	if err != nil {
+		return resp, err
+	}
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == server.SessionIdName && cookie.Value != "" && cookie.Value != c.sessionId {
+			c.sessionId = cookie.Value
+			c.SetHeader(server.SessionIdName, cookie.Value)
+		}
+	}
+	return resp, nil
+}
+
+// SessionId 当前会话id
+func (c *Client) SessionId() string {
+	return c.sessionId
+}
+
+// Signed 为请求添加签名，用于测试server.MiddlewareSignature保护的接口
+func (c *Client) Signed(appKey string, appSecret string) *Client {
+	c.Use(utils.NewSigner(appKey, appSecret).Middleware)
+	return c
+}
+
+// Request 发送请求并解析Json响应
+/*
+ * 响应不是Json格式时Code、Data、Msg为零值，可通过Body断言
+ * @param c *Client 客户端
+ * @param method string 请求方法
+ * @param path string 请求路径
+ * @param data ...any 请求参数，GET请求为查询参数
+ * @return *Response[T] 响应
+ */
+func Request[T any](c *Client, method string, path string, data ...any) *Response[T] {
+	c.t.Helper()
+	resp, err := c.DoRequest(context.Background(), method, path, data...)
+	if err != nil {
+		c.t.Fatalf("%s %s 请求失败: %v", method, path, err)
+	}
+	defer resp.Close()
+	res := &Response[T]{
+		t:      c.t,
+		Status: resp.StatusCode,
+		Header: resp.Header,
+		Body:   resp.ReadAll(),
+	}
+	var body envelope[T]
+	if json.Unmarshal(res.Body, &body) == nil {
+		res.Code, res.Data, res.Msg = body.Code, body.Data, body.Msg
+	}
+	return res
+}
+
+// Get 发送GET请求
+func Get[T any](c *Client, path string, data ...any) *Response[T] {
+	c.t.Helper()
+	return Request[T](c, http.MethodGet, path, data...)
+}
+
+// Post 发送POST请求
+func Post[T any](c *Client, path string, data ...any) *Response[T] {
+	c.t.Helper()
+	return Request[T](c, http.MethodPost, path, data...)
+}
+
+// AssertStatus 断言http状态码
+func (r *Response[T]) AssertStatus(status int) *Response[T] {
+	r.t.Helper()
+	if r.Status != status {
+		r.t.Fatalf("http状态码为%d，期望%d，响应：%s", r.Status, status, r.Body)
+	}
+	return r
+}
+
+// AssertCode 断言Json.Code
+func (r *Response[T]) AssertCode(code int) *Response[T] {
+	r.t.Helper()
+	if r.Code != code {
+		r.t.Fatalf("code为%d，期望%d，响应：%s", r.Code, code, r.Body)
+	}
+	return r
+}
+
+// Golden 将响应体与testdata/name.golden比较，见AssertGolden
+func (r *Response[T]) Golden(name string, ignore ...string) *Response[T] {
+	r.t.Helper()
+	AssertGolden(r.t, name, r.Body, ignore...)
+	return r
+}
@@ -0,0 +1,91 @@
+package servertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// update 使用go test -update重新生成golden文件
+var update = flag.Bool("update", false, "更新servertest的golden文件")
+
+// ignoredValue 忽略字段替换后的值
+const ignoredValue = "<ignored>"
+
+// AssertGolden 将内容与testdata/name.golden比较，不一致时测试失败
+/*
+ * Json内容会格式化后比较，ignore中的字段（任意层级）替换为<ignored>，用于时间、id等每次变化的值
+ * 使用go test -update运行时写入当前内容，文件不存在时测试失败，防止缺失或名称错误的文件在CI中通过
+ * @param t testing.TB 测试
+ * @param name string 文件名，不含扩展名
+ * @param got []byte 实际内容
+ * @param ignore ...string 忽略的字段名
+ */
+func AssertGolden(t testing.TB, name string, got []byte, ignore ...string) {
+	t.Helper()
+	got = normalize(got, ignore)
+	path := filepath.Join("testdata", name+".golden")
+	want, err := os.ReadFile(path)
+	if *update {
+		if err = os.MkdirAll(filepath.Dir(path), 0o755); err == nil {
+			err = os.WriteFile(path, got, 0o644)
+		}
+		if err != nil {
+			t.Fatalf("写入golden文件失败: %v", err)
+		}
+		return
+	}
+	if os.IsNotExist(err) {
+		t.Fatalf("golden文件%s不存在，请使用go test -update运行生成", path)
+	}
+	if err != nil {
+		t.Fatalf("读取golden文件失败: %v", err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Fatalf("内容与%s不一致\n期望:\n%s\n实际:\n%s", path, want, got)
+	}
+}
+
+// normalize 格式化Json并替换忽略的字段，非Json内容原样返回
+func normalize(body []byte, ignore []string) []byte {
+	var value any
+	if json.Unmarshal(body, &value) != nil {
+		return body
+	}
+	keys := make(map[string]bool, len(ignore))
+	for _, key := range ignore {
+		keys[key] = true
+	}
+	var (
+		out     bytes.Buffer
+		encoder = json.NewEncoder(&out)
+	)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if encoder.Encode(redact(value, keys)) != nil {
+		return body
+	}
+	return out.Bytes()
+}
+
+// redact 递归替换忽略的字段
+func redact(value any, keys map[string]bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if keys[key] {
+				v[key] = ignoredValue
+			} else {
+				v[key] = redact(item, keys)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redact(item, keys)
+		}
+	}
+	return value
+}
@@ -0,0 +1,127 @@
+// Package servertest 处理函数与中间件的测试工具，在进程内启动服务，不依赖外部服务
+/*
+ * 例：
+ *	func TestUserInfo(t *testing.T) {
+ *		s := servertest.New(t, func(s *ghttp.Server) {
+ *			s.Group("/admin", func(group *ghttp.RouterGroup) {
+ *				group.Middleware(server.AuthAdmin)
+ *				group.Bind(controller.User)
+ *			})
+ *		})
+ *		res := servertest.Get[UserInfoRes](s.LoginAdmin(g.Map{"id": 1}), "/admin/user/info")
+ *		res.AssertCode(1)
+ *		res.Golden("user_info", "createTime")
+ *	}
+ */
+package servertest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/black1552/base-common/server"
+	"github.com/gogf/gf/v2/container/gmap"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gsession"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+// Server 测试服务
+type Server struct {
+	*ghttp.Server
+	t       testing.TB
+	storage *gsession.StorageMemory
+	ttl     time.Duration
+}
+
+// New 在随机端口启动测试服务，使用与server.Start相同的全局中间件，测试结束时自动关闭
+/*
+ * 会话保存在内存中，不写入resource目录
+ * @param t testing.TB 测试
+ * @param register func(s *ghttp.Server) 注册路由
+ * @return *Server 测试服务
+ */
+func New(t testing.TB, register func(s *ghttp.Server)) *Server {
+	t.Helper()
+	s := &Server{
+		Server:  g.Server("servertest-" + guid.S()),
+		t:       t,
+		storage: gsession.NewStorageMemory(),
+		ttl:     time.Hour,
+	}
+	s.SetPort(0)
+	s.SetDumpRouterMap(false)
+	s.SetAccessLogEnabled(false)
+	s.SetErrorLogEnabled(false)
+	s.SetSessionIdName(server.SessionIdName)
+	s.SetSessionStorage(s.storage)
+	s.SetSessionMaxAge(s.ttl)
+	s.Use(server.Middlewares()...)
+	s.BindHookHandler("/*any", ghttp.HookBeforeOutput, server.HookBodyLog)
+	if register != nil {
+		register(s.Server)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("启动测试服务失败: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = s.Shutdown()
+	})
+	return s
+}
+
+// URL 服务地址，如http://127.0.0.1:12345
+func (s *Server) URL() string {
+	return fmt.Sprintf("http://127.0.0.1:%d", s.GetListenedPort())
+}
+
+// Client 创建未登录的客户端
+func (s *Server) Client() *Client {
+	return newClient(s.t, s.URL())
+}
+
+// Login 写入会话数据并返回携带该会话的客户端
+/*
+ * @param data map[string]any 会话数据，如g.Map{"admin": info}
+ * @return *Client 客户端
+ */
+func (s *Server) Login(data map[string]any) *Client {
+	s.t.Helper()
+	id := guid.S()
+	if err := s.storage.SetSession(context.Background(), id, gmap.NewStrAnyMapFrom(data, true), s.ttl); err != nil {
+		s.t.Fatalf("写入会话失败: %v", err)
+	}
+	c := s.Client()
+	c.SetHeader(server.SessionIdName, id)
+	c.sessionId = id
+	return c
+}
+
+// LoginAdmin 以后端用户登录，可通过server.AuthAdmin
+func (s *Server) LoginAdmin(info any) *Client {
+	return s.Login(map[string]any{"admin": info})
+}
+
+// LoginUser 以前端用户登录，可通过server.AuthIndex
+func (s *Server) LoginUser(info any) *Client {
+	return s.Login(map[string]any{"user": info})
+}
+
+// Session 读取客户端当前的会话数据，用于断言处理函数写入的内容
+func (s *Server) Session(c *Client) map[string]any {
+	s.t.Helper()
+	if c.sessionId == "" {
+		return nil
+	}
+	data, err := s.storage.GetSession(context.Background(), c.sessionId, s.ttl)
+	if err != nil {
+		s.t.Fatalf("读取会话失败: %v", err)
+	}
+	if data == nil {
+		return nil
+	}
+	return data.Map()
+}
@@ -0,0 +1,105 @@
+package servertest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/black1552/base-common/server"
+	"github.com/black1552/base-common/server/servertest"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+type infoReq struct {
+	g.Meta `path:"/info" method:"get"`
+	Name   string `json:"name" v:"required"`
+}
+
+type infoRes struct {
+	Name  string `json:"name"`
+	Admin any    `json:"admin"`
+}
+
+type counterReq struct {
+	g.Meta `path:"/counter" method:"post"`
+}
+
+type counterRes struct {
+	Count int `json:"count"`
+}
+
+func newServer(t *testing.T) *servertest.Server {
+	return servertest.New(t, func(s *ghttp.Server) {
+		s.Group("/", func(group *ghttp.RouterGroup) {
+			group.Bind(
+				func(ctx context.Context, req *counterReq) (*counterRes, error) {
+					r := g.RequestFromCtx(ctx)
+					count := r.Session.MustGet("count", 0).Int() + 1
+					return &counterRes{Count: count}, r.Session.Set("count", count)
+				},
+			)
+			group.GET("/fail", func(r *ghttp.Request) {
+				r.SetError(errors.New("操作失败"))
+			})
+		})
+		s.Group("/admin", func(group *ghttp.RouterGroup) {
+			group.Middleware(server.AuthAdmin)
+			group.Bind(func(ctx context.Context, req *infoReq) (*infoRes, error) {
+				return &infoRes{Name: req.Name, Admin: g.RequestFromCtx(ctx).Session.MustGet("admin").Map()}, nil
+			})
+		})
+	})
+}
+
+// TestEnvelope 处理函数返回值经MiddlewareError包装为Json
+func TestEnvelope(t *testing.T) {
+	s := newServer(t)
+	c := s.LoginAdmin(g.Map{"id": 1, "name": "admin"})
+
+	res := servertest.Get[infoRes](c, "/admin/info", g.Map{"name": "test"}).
+		AssertStatus(http.StatusOK).
+		AssertCode(1).
+		Golden("envelope")
+	if res.Data.Name != "test" || res.Msg != "操作成功" {
+		t.Fatalf("响应数据不正确: %+v", res)
+	}
+
+	servertest.Get[any](c, "/fail").AssertStatus(http.StatusInternalServerError).AssertCode(0)
+	res = servertest.Get[infoRes](c, "/admin/info").AssertStatus(http.StatusBadRequest).AssertCode(0)
+	if res.Msg == "" {
+		t.Fatal("参数校验失败时缺少错误信息")
+	}
+}
+
+// TestLogin 登录客户端可通过鉴权，未登录客户端返回401
+func TestLogin(t *testing.T) {
+	s := newServer(t)
+	servertest.Get[any](s.Client(), "/admin/info", g.Map{"name": "test"}).AssertStatus(http.StatusUnauthorized).AssertCode(401)
+	servertest.Get[any](s.LoginUser(g.Map{"id": 1}), "/admin/info", g.Map{"name": "test"}).AssertStatus(http.StatusUnauthorized)
+
+	c := s.LoginAdmin(g.Map{"id": 2})
+	res := servertest.Get[infoRes](c, "/admin/info", g.Map{"name": "test"}).AssertCode(1)
+	if admin, ok := res.Data.Admin.(map[string]any); !ok || admin["id"] != float64(2) {
+		t.Fatalf("处理函数读取的会话不正确: %+v", res.Data.Admin)
+	}
+}
+
+// TestSession 客户端保存服务端下发的会话，Session可读取处理函数写入的数据
+func TestSession(t *testing.T) {
+	s := newServer(t)
+	c := s.Client()
+	for want := 1; want <= 2; want++ {
+		res := servertest.Post[counterRes](c, "/counter").AssertCode(1)
+		if res.Data.Count != want {
+			t.Fatalf("count为%d，期望%d", res.Data.Count, want)
+		}
+	}
+	if c.SessionId() == "" {
+		t.Fatal("未保存会话id")
+	}
+	if count := s.Session(c)["count"]; count != 2 {
+		t.Fatalf("会话中的count为%v，期望2", count)
+	}
+}
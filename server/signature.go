@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/black1552/base-common/utils"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/os/gcache"
+)
+
+// SignatureAppKeyCtx 签名校验通过后写入上下文的应用标识键
+const SignatureAppKeyCtx = "signatureAppKey"
+
+// SignatureSecretFunc 根据应用标识获取应用密钥，应用不存在或已禁用时返回错误
+type SignatureSecretFunc func(ctx context.Context, appKey string) (string, error)
+
+// SignatureOption 签名校验配置，签名方式见utils.SignCanonical
+type SignatureOption struct {
+	Secret  SignatureSecretFunc // 获取应用密钥
+	MaxSkew time.Duration       // 允许的时间误差，默认5分钟
+	Nonce   *gcache.Cache       // 随机串缓存，用于防重放，集群部署时可使用redis适配器
+}
+
+// MiddlewareSignature 第三方接口签名校验中间件
+/*
+ * 请求需携带X-App-Key、X-Timestamp、X-Nonce、X-Signature请求头
+ * 校验通过后可通过SignatureAppKey(ctx)获取应用标识
+ * @param option *SignatureOption 签名校验配置
+ * @return ghttp.HandlerFunc 中间件
+ */
+func MiddlewareSignature(option *SignatureOption) ghttp.HandlerFunc {
+	if option.MaxSkew <= 0 {
+		option.MaxSkew = 5 * time.Minute
+	}
+	if option.Nonce == nil {
+		option.Nonce = gcache.New()
+	}
+	return func(r *ghttp.Request) {
+		appKey, err := verifySignature(r, option)
+		if err != nil {
+			r.Response.Status = http.StatusUnauthorized
+			r.Response.WriteJsonExit(Json{
+				Code: http.StatusUnauthorized,
+				Data: nil,
+				Msg:  err.Error(),
+			})
+			return
+		}
+		r.SetCtxVar(SignatureAppKeyCtx, appKey)
+		r.Middleware.Next()
+	}
+}
+
+// verifySignature 校验签名，返回应用标识
+func verifySignature(r *ghttp.Request, option *SignatureOption) (string, error) {
+	var (
+		ctx       = r.Context()
+		appKey    = r.Header.Get(utils.SignAppKeyHeader)
+		timestamp = r.Header.Get(utils.SignTimestampHeader)
+		nonce     = r.Header.Get(utils.SignNonceHeader)
+		signature = r.Header.Get(utils.SignatureHeader)
+	)
+	if appKey == "" || timestamp == "" || nonce == "" || signature == "" {
+		return "", errors.New("缺少签名参数")
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", errors.New("时间戳格式错误")
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > option.MaxSkew || skew < -option.MaxSkew {
+		return "", errors.New("请求已过期")
+	}
+	secret, err := option.Secret(ctx, appKey)
+	if err != nil || secret == "" {
+		return "", errors.New("应用不存在或已禁用")
+	}
+	canonical := utils.SignCanonical(r.Method, r.URL.EscapedPath(), r.URL.RawQuery, r.GetBody(), timestamp, nonce)
+	if !hmac.Equal([]byte(signature), []byte(utils.SignHmac(secret, canonical))) {
+		return "", errors.New("签名错误")
+	}
+	// 签名正确后再记录随机串，避免伪造请求占用随机串
+	ok, err := option.Nonce.SetIfNotExist(ctx, appKey+":"+nonce, true, option.MaxSkew*2)
+	if err != nil || !ok {
+		return "", errors.New("重复的请求")
+	}
+	return appKey, nil
+}
+
+// SignatureAppKey 获取签名校验通过的应用标识
+func SignatureAppKey(ctx context.Context) string {
+	r := ghttp.RequestFromCtx(ctx)
+	if r == nil {
+		return ""
+	}
+	return r.GetCtxVar(SignatureAppKeyCtx).String()
+}
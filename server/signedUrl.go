@@ -0,0 +1,226 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/black1552/base-common/task"
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/text/gstr"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// 签名URL的查询参数名
+const (
+	SignedUrlExpires = "expires"
+	SignedUrlUser    = "uid"
+	SignedUrlSign    = "sign"
+)
+
+// SignedStaticConfig 私有静态文件配置
+type SignedStaticConfig struct {
+	Secret      string                        // HMAC签名密钥，为空时随机生成（重启后已签发的链接失效）
+	PrivateDirs []string                      // 私有目录，相对于resource，如excel、public/upload/idcard
+	DefaultTTL  time.Duration                 // 默认有效期
+	UserId      func(r *ghttp.Request) string // 获取当前访问用户标识，用于校验链接绑定的用户，默认读取session中user的id
+}
+
+// sSignedStatic 私有静态文件签名
+type sSignedStatic struct {
+	config SignedStaticConfig
+	mutex  sync.RWMutex
+}
+
+var SignedStatic = &sSignedStatic{
+	config: SignedStaticConfig{
+		Secret:      randomHex(32),
+		PrivateDirs: []string{},
+		DefaultTTL:  30 * time.Minute,
+		UserId:      sessionUserId,
+	},
+}
+
+// Init 初始化私有静态文件配置
+func (s *sSignedStatic) Init(config SignedStaticConfig) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if config.Secret == "" {
+		config.Secret = randomHex(32)
+	}
+	if config.DefaultTTL <= 0 {
+		config.DefaultTTL = s.config.DefaultTTL
+	}
+	if config.UserId == nil {
+		config.UserId = sessionUserId
+	}
+	dirs := make([]string, 0, len(config.PrivateDirs))
+	for _, dir := range config.PrivateDirs {
+		if dir = gstr.Trim(path.Clean("/"+gstr.Replace(dir, "\\", "/")), "/"); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	config.PrivateDirs = dirs
+	s.config = config
+}
+
+// IsPrivate 判断resource下的相对路径是否处于私有目录
+// 不区分大小写，防止在不区分大小写的文件系统上通过/static/PRIVATE/...绕过签名校验
+func (s *sSignedStatic) IsPrivate(relPath string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	relPath = gstr.ToLower(gstr.Trim(path.Clean("/"+gstr.Replace(relPath, "\\", "/")), "/"))
+	for _, dir := range s.config.PrivateDirs {
+		dir = gstr.ToLower(dir)
+		if relPath == dir || gstr.HasPrefix(relPath, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sign 计算签名
+func (s *sSignedStatic) sign(relPath string, expires int64, uid string) string {
+	s.mutex.RLock()
+	secret := s.config.Secret
+	s.mutex.RUnlock()
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%s\n%d\n%s", relPath, expires, uid)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验请求是否携带有效签名
+func (s *sSignedStatic) Verify(r *ghttp.Request, relPath string) error {
+	var (
+		query   = r.URL.Query()
+		expires = gconv.Int64(query.Get(SignedUrlExpires))
+		uid     = query.Get(SignedUrlUser)
+		sign    = query.Get(SignedUrlSign)
+	)
+	if sign == "" || expires == 0 {
+		return fmt.Errorf("无权访问该文件")
+	}
+	if time.Now().Unix() > expires {
+		return fmt.Errorf("文件链接已过期")
+	}
+	if !hmac.Equal([]byte(sign), []byte(s.sign(relPath, expires, uid))) {
+		return fmt.Errorf("文件链接签名无效")
+	}
+	if uid != "" {
+		s.mutex.RLock()
+		userId := s.config.UserId
+		s.mutex.RUnlock()
+		if userId(r) != uid {
+			return fmt.Errorf("无权访问该文件")
+		}
+	}
+	return nil
+}
+
+// SignStaticUrl 生成带签名和有效期的静态文件访问地址
+/*
+ * @param filePath string 文件路径，支持/static/...、/uploads/...、resource下的相对路径或绝对路径
+ * @param ttl time.Duration 有效期，<=0时使用默认有效期
+ * @param userId ...string 绑定的用户标识，绑定后仅该用户可访问
+ * @return string 访问地址
+ */
+func SignStaticUrl(filePath string, ttl time.Duration, userId ...string) string {
+	relPath := staticRelPath(filePath)
+	if ttl <= 0 {
+		SignedStatic.mutex.RLock()
+		ttl = SignedStatic.config.DefaultTTL
+		SignedStatic.mutex.RUnlock()
+	}
+	var (
+		expires = time.Now().Add(ttl).Unix()
+		uid     string
+	)
+	if len(userId) > 0 {
+		uid = userId[0]
+	}
+	query := url.Values{}
+	query.Set(SignedUrlExpires, gconv.String(expires))
+	if uid != "" {
+		query.Set(SignedUrlUser, uid)
+	}
+	query.Set(SignedUrlSign, SignedStatic.sign(relPath, expires, uid))
+	segments := gstr.Split(relPath, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return "/static/" + gstr.Join(segments, "/") + "?" + query.Encode()
+}
+
+// SignTaskUrl 为任务生成的文件生成签名地址
+/*
+ * @param token string 任务标识
+ * @param ttl time.Duration 有效期
+ * @param userId ...string 绑定的用户标识
+ * @return string 访问地址，任务不存在或未生成文件时为空
+ */
+func SignTaskUrl(token string, ttl time.Duration, userId ...string) string {
+	t, ok := task.Manager.SnapshotTask(token)
+	if !ok || t.Path == "" {
+		return ""
+	}
+	return SignStaticUrl(t.Path, ttl, userId...)
+}
+
+// staticRelPath 将各种形式的文件路径转换为resource下的相对路径
+func staticRelPath(filePath string) string {
+	filePath = gstr.Replace(filePath, "\\", "/")
+	resource := gstr.Replace(uploadPath, "\\", "/")
+	switch {
+	case gstr.HasPrefix(filePath, resource+"/"):
+		filePath = gstr.TrimLeftStr(filePath, resource+"/")
+	case gstr.HasPrefix(filePath, "/static/"):
+		filePath = gstr.TrimLeftStr(filePath, "/static/")
+	case gstr.HasPrefix(filePath, "/uploads/"):
+		// excel.CreateExcel返回的地址
+		filePath = gstr.TrimLeftStr(filePath, "/uploads/")
+	}
+	return gstr.Trim(path.Clean("/"+filePath), "/")
+}
+
+// sessionUserId 默认从session的user中读取用户标识
+func sessionUserId(r *ghttp.Request) string {
+	user, err := r.Session.Get("user", nil)
+	if err != nil || user.IsNil() {
+		return ""
+	}
+	if m := user.Map(); len(m) > 0 {
+		return gconv.String(m["id"])
+	}
+	return user.String()
+}
+
+// randomHex 生成随机十六进制字符串
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// hookSignedStatic 拦截私有目录下未签名的静态文件请求
+func hookSignedStatic(r *ghttp.Request) {
+	relPath := gstr.Trim(path.Clean("/"+gstr.Replace(gstr.TrimLeftStr(r.URL.Path, "/static"), "\\", "/")), "/")
+	if !SignedStatic.IsPrivate(relPath) {
+		return
+	}
+	if err := SignedStatic.Verify(r, relPath); err != nil {
+		r.Response.Status = http.StatusForbidden
+		r.Response.WriteJson(Json{
+			Code: http.StatusForbidden,
+			Data: nil,
+			Msg:  err.Error(),
+		})
+		r.ExitAll()
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/black1552/base-common/task"
+	"github.com/gogf/gf/v2/text/gstr"
+)
+
+func TestSignTaskUrlRace(t *testing.T) {
+	const token = "signTaskUrlRace"
+	task.Manager.CreateTask(token, 1)
+	defer task.Manager.RemoveTask(token)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		task.Manager.CompleteTask(token, "完成", "/uploads/excel/export.xlsx")
+	}()
+	urls := make([]string, 0, 100)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			urls = append(urls, SignTaskUrl(token, time.Minute))
+		}
+	}()
+	wg.Wait()
+
+	for _, u := range urls {
+		if u != "" && !gstr.HasPrefix(u, "/static/excel/export.xlsx?") {
+			t.Fatalf("签名地址错误：%s", u)
+		}
+	}
+	if u := SignTaskUrl(token, time.Minute); !gstr.HasPrefix(u, "/static/excel/export.xlsx?") {
+		t.Fatalf("任务完成后签名地址错误：%s", u)
+	}
+	if u := SignTaskUrl("notExists", time.Minute); u != "" {
+		t.Fatalf("任务不存在时应返回空地址：%s", u)
+	}
+}
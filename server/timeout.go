@@ -0,0 +1,190 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/encoding/gjson"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// MiddlewareTimeout 请求超时中间件，为路由组设置上下文截止时间，超时返回504
+/*
+ * 截止时间到达时立即向客户端输出504并关闭连接，处理函数之后的输出和错误被丢弃；
+ * 已经开始输出（如流式下载、WebSocket）的请求不再输出504。
+ * 处理函数无法被强制终止，需将r.Context()传递给数据库、http客户端等调用，
+ * 或在循环中检查r.Context().Done()，才能在超时后尽快释放资源。
+ * 需在MiddlewareError之后注册，例：
+ *	group.Middleware(server.MiddlewareTimeout(5 * time.Second))
+ * 嵌套使用时以较早的截止时间为准
+ * @param timeout time.Duration 超时时间
+ * @return ghttp.HandlerFunc 中间件
+ */
+func MiddlewareTimeout(timeout time.Duration) ghttp.HandlerFunc {
+	return func(r *ghttp.Request) {
+		if timeout <= 0 {
+			r.Middleware.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r.SetCtx(ctx)
+
+		// 处理函数只写入gf的缓冲区，超时响应由计时协程直接写入底层连接，两者通过timeoutWriter互斥
+		writer := newTimeoutWriter(r.Response.Writer.ResponseWriter)
+		r.Response.Writer.ResponseWriter = writer
+		deadline, _ := ctx.Deadline()
+		timer := time.AfterFunc(time.Until(deadline), writer.timeout)
+		defer func() {
+			timer.Stop()
+			writer.finish()
+		}()
+
+		r.Middleware.Next()
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return
+		}
+		// 超时后处理函数的输出和错误不再返回给客户端，缓冲区中的504供日志记录
+		r.SetError(nil)
+		r.Response.ClearBuffer()
+		r.Response.Status = http.StatusGatewayTimeout
+		r.Response.WriteJson(timeoutJson)
+	}
+}
+
+// timeoutJson 超时响应
+var timeoutJson = Json{
+	Code: http.StatusGatewayTimeout,
+	Data: nil,
+	Msg:  "请求超时，请稍后重试",
+}
+
+// timeoutWriter 超时后丢弃处理函数输出的ResponseWriter
+/*
+ * 处理函数设置的响应头先写入header，开始输出时再复制到底层连接，
+ * 避免计时协程输出504时与处理函数并发修改响应头
+ */
+type timeoutWriter struct {
+	http.ResponseWriter
+	header   http.Header
+	mutex    sync.Mutex
+	wrote    bool // 处理函数已开始输出
+	timedOut bool // 已输出504
+	finished bool // 中间件已返回，计时协程不能再写入底层连接
+}
+
+// newTimeoutWriter 创建超时ResponseWriter，继承已设置的响应头
+func newTimeoutWriter(w http.ResponseWriter) *timeoutWriter {
+	return &timeoutWriter{ResponseWriter: w, header: w.Header().Clone()}
+}
+
+// Header 处理函数设置的响应头
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader 输出状态码，超时后丢弃
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.timedOut {
+		return
+	}
+	w.commit()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write 输出内容，超时后丢弃
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.commit()
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush 刷新输出，超时后不做处理
+func (w *timeoutWriter) Flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.timedOut {
+		return
+	}
+	w.commit()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 接管连接，如升级WebSocket，接管后不再输出504
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.timedOut {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("连接不支持接管")
+	}
+	w.wrote = true
+	return hijacker.Hijack()
+}
+
+// Unwrap 获取底层ResponseWriter，供http.ResponseController使用
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// commit 处理函数开始输出，复制响应头到底层连接，需持有锁
+func (w *timeoutWriter) commit() {
+	if w.wrote {
+		return
+	}
+	w.wrote = true
+	dst := w.ResponseWriter.Header()
+	for key := range dst {
+		if _, ok := w.header[key]; !ok {
+			dst.Del(key)
+		}
+	}
+	for key, values := range w.header {
+		dst[key] = values
+	}
+}
+
+// timeout 截止时间到达，处理函数尚未输出时立即输出504
+func (w *timeoutWriter) timeout() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.wrote || w.finished {
+		return
+	}
+	w.timedOut = true
+	body, _ := gjson.Encode(timeoutJson)
+	header := w.ResponseWriter.Header()
+	header.Set("Content-Type", "application/json")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	// 处理函数仍在执行，关闭连接使客户端不再等待复用
+	header.Set("Connection", "close")
+	w.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+	_, _ = w.ResponseWriter.Write(body)
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// finish 中间件返回，之后由gf输出缓冲区，计时协程不再写入
+func (w *timeoutWriter) finish() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.finished = true
+}
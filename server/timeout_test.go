@@ -0,0 +1,47 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/black1552/base-common/server"
+	"github.com/black1552/base-common/server/servertest"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// TestMiddlewareTimeout 处理函数忽略上下文时仍按时返回504
+func TestMiddlewareTimeout(t *testing.T) {
+	done := make(chan struct{})
+	s := servertest.New(t, func(s *ghttp.Server) {
+		s.Group("/", func(group *ghttp.RouterGroup) {
+			group.Middleware(server.MiddlewareTimeout(100 * time.Millisecond))
+			group.GET("/slow", func(r *ghttp.Request) {
+				defer close(done)
+				time.Sleep(500 * time.Millisecond)
+				r.Response.Header().Set("X-Slow", "1")
+				r.Response.Write("slow")
+			})
+			group.GET("/fast", func(r *ghttp.Request) {
+				r.Response.Header().Set("X-Fast", "1")
+				r.Response.WriteJson(server.Json{Code: http.StatusOK, Msg: "ok"})
+			})
+		})
+	})
+	c := s.Client()
+
+	start := time.Now()
+	res := servertest.Get[any](c, "/slow").AssertStatus(http.StatusGatewayTimeout).AssertCode(http.StatusGatewayTimeout)
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("超时响应耗时%v，处理函数结束前未返回504", elapsed)
+	}
+	if res.Header.Get("X-Slow") != "" {
+		t.Fatalf("超时后不应输出处理函数的响应头")
+	}
+	<-done
+
+	res = servertest.Get[any](c, "/fast").AssertStatus(http.StatusOK).AssertCode(http.StatusOK)
+	if res.Header.Get("X-Fast") != "1" {
+		t.Fatalf("未超时时应保留处理函数的响应头")
+	}
+}
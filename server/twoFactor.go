@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// twoFactorSuffix 等待二次验证的登录信息的session键后缀，如adminTwoFactor
+const twoFactorSuffix = "TwoFactor"
+
+// twoFactorFailedSuffix 二次验证失败次数的session键后缀，如adminTwoFactorFailed
+const twoFactorFailedSuffix = "TwoFactorFailed"
+
+// TwoFactorMaxAttempts 每次密码登录后允许的二次验证次数，用完后需重新输入密码
+var TwoFactorMaxAttempts = 5
+
+// ErrTwoFactorAttempts 二次验证失败次数过多
+var ErrTwoFactorAttempts = errors.New("二次验证失败次数过多，请重新登录")
+
+// twoFactorLocks 按会话分段加锁，保证同一会话并发验证时逐次扣减次数
+var twoFactorLocks [64]sync.Mutex
+
+// TwoFactorPending 密码校验通过、等待二次验证，登录信息暂存在独立的待验证键下，代替写入登录session
+// 二次验证通过前会话不持有sessionKey，AuthBase、AuthAdmin拒绝访问，前端根据返回的twoFactorPending跳转到二次验证页
+/*
+ * @param ctx context.Context 请求上下文
+ * @param sessionKey string 登录信息的session键，如admin
+ * @param info any 登录信息，TwoFactorPassed时写入sessionKey
+ */
+func TwoFactorPending(ctx context.Context, sessionKey string, info any) error {
+	r := g.RequestFromCtx(ctx)
+	if r == nil {
+		return errors.New("无法获取请求信息")
+	}
+	if info == nil {
+		return errors.New("登录信息不能为空")
+	}
+	// 同一会话重新登录时，清除已有的登录状态
+	if err := r.Session.Remove(sessionKey, sessionKey+twoFactorFailedSuffix); err != nil {
+		return err
+	}
+	return r.Session.Set(sessionKey+twoFactorSuffix, info)
+}
+
+// TwoFactorPassed 二次验证通过，将待验证的登录信息写入sessionKey
+func TwoFactorPassed(ctx context.Context, sessionKey string) error {
+	r := g.RequestFromCtx(ctx)
+	if r == nil {
+		return errors.New("无法获取请求信息")
+	}
+	info, err := r.Session.Get(sessionKey+twoFactorSuffix, nil)
+	if err != nil {
+		return err
+	}
+	if info.IsEmpty() {
+		return errors.New("没有等待二次验证的登录")
+	}
+	if err = r.Session.Set(sessionKey, info.Val()); err != nil {
+		return err
+	}
+	return r.Session.Remove(sessionKey+twoFactorSuffix, sessionKey+twoFactorFailedSuffix)
+}
+
+// VerifyTwoFactor 校验二次验证，通过时写入登录信息，失败次数达到TwoFactorMaxAttempts时清除待验证的登录
+/*
+ * 每次校验前先扣减一次次数，同一会话并发提交也不能超过限制
+ * 例：
+ *	err := server.VerifyTwoFactor(ctx, "admin", func(info *gvar.Var) bool {
+ *		admin := info.Map()
+ *		return totp.Verify(ctx, gconv.String(admin["account"]), secretOf(admin), req.Code)
+ *	})
+ * @param ctx context.Context 请求上下文
+ * @param sessionKey string 登录信息的session键，如admin
+ * @param verify func(info *gvar.Var) bool 根据待验证的登录信息校验验证码或恢复码
+ * @return error 验证失败时返回剩余次数，次数用完时返回ErrTwoFactorAttempts
+ */
+func VerifyTwoFactor(ctx context.Context, sessionKey string, verify func(info *gvar.Var) bool) error {
+	r := g.RequestFromCtx(ctx)
+	if r == nil {
+		return errors.New("无法获取请求信息")
+	}
+	info, remain, err := twoFactorAttempt(r, sessionKey)
+	if err != nil {
+		return err
+	}
+	if verify(info) {
+		// 最后一次机会时待验证的登录信息已清除，直接写入sessionKey
+		if err = r.Session.Set(sessionKey, info.Val()); err != nil {
+			return err
+		}
+		return r.Session.Remove(sessionKey+twoFactorSuffix, sessionKey+twoFactorFailedSuffix)
+	}
+	if remain <= 0 {
+		return ErrTwoFactorAttempts
+	}
+	return fmt.Errorf("验证码错误，还可尝试%d次", remain)
+}
+
+// twoFactorAttempt 扣减一次二次验证次数，返回待验证的登录信息和剩余次数
+func twoFactorAttempt(r *ghttp.Request, sessionKey string) (*gvar.Var, int, error) {
+	id, err := r.Session.Id()
+	if err != nil {
+		return nil, 0, err
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(id + sessionKey))
+	lock := &twoFactorLocks[hash.Sum32()%uint32(len(twoFactorLocks))]
+	lock.Lock()
+	defer lock.Unlock()
+
+	info, err := r.Session.Get(sessionKey+twoFactorSuffix, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if info.IsEmpty() {
+		return nil, 0, errors.New("没有等待二次验证的登录")
+	}
+	failed, err := r.Session.Get(sessionKey+twoFactorFailedSuffix, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	count := failed.Int() + 1
+	if count >= TwoFactorMaxAttempts {
+		// 最后一次机会，之后需重新输入密码
+		err = r.Session.Remove(sessionKey+twoFactorSuffix, sessionKey+twoFactorFailedSuffix)
+	} else {
+		err = r.Session.Set(sessionKey+twoFactorFailedSuffix, count)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return info, TwoFactorMaxAttempts - count, nil
+}
+
+// TwoFactorInfo 获取等待二次验证的登录信息，用于查找该用户的TOTP密钥
+func TwoFactorInfo(ctx context.Context, sessionKey string) (*gvar.Var, error) {
+	r := g.RequestFromCtx(ctx)
+	if r == nil {
+		return nil, errors.New("无法获取请求信息")
+	}
+	return r.Session.Get(sessionKey+twoFactorSuffix, nil)
+}
+
+// IsTwoFactorPending 当前会话是否处于等待二次验证状态
+func IsTwoFactorPending(r *ghttp.Request, sessionKey string) bool {
+	pending, err := r.Session.Get(sessionKey+twoFactorSuffix, nil)
+	return err == nil && !pending.IsEmpty()
+}
+
+// AuthTwoFactorPending 二次验证接口使用的鉴权中间件，仅允许密码已通过、等待二次验证的会话访问
+// 接口中使用VerifyTwoFactor校验，失败次数达到TwoFactorMaxAttempts后会话需重新登录
+func AuthTwoFactorPending(sessionKey string) ghttp.HandlerFunc {
+	return func(r *ghttp.Request) {
+		if !IsTwoFactorPending(r, sessionKey) {
+			NoLogin(r)
+			return
+		}
+		r.Middleware.Next()
+	}
+}
+
+// noTwoFactor 未完成二次验证返回
+func noTwoFactor(r *ghttp.Request) {
+	r.Response.Status = 401
+	r.Response.WriteJsonExit(Json{
+		Code: 401,
+		Data: g.Map{"twoFactorPending": true},
+		Msg:  "请完成二次验证",
+	})
+}
@@ -2,14 +2,18 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"path/filepath"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/gogf/gf/v2/os/glog"
 
 	"github.com/gogf/gf/v2/frame/g"
+	"github.com/gogf/gf/v2/i18n/gi18n"
 	"github.com/gogf/gf/v2/net/ghttp"
 	"github.com/gogf/gf/v2/net/goai"
 	"github.com/gogf/gf/v2/os/gcfg"
@@ -18,6 +22,7 @@ import (
 	"github.com/gogf/gf/v2/os/gtime"
 	"github.com/gogf/gf/v2/text/gstr"
 	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/gogf/gf/v2/util/gvalid"
 )
 
 type Json struct {
@@ -163,7 +168,16 @@ func MiddlewareError(r *ghttp.Request) {
 	json.Code = 1
 	json.Data = res
 	json.Msg = "操作成功"
-	if err != nil {
+	var vErr gvalid.Error
+	if err != nil && errors.As(err, &vErr) {
+		// 参数校验失败，按字段返回错误信息
+		data := validationErrorData(r, vErr)
+		r.Response.ClearBuffer()
+		json.Code = 0
+		json.Data = data
+		json.Msg = gi18n.T(r.Context(), vErr.FirstError().Error())
+		r.Response.Status = http.StatusBadRequest
+	} else if err != nil {
 		bo := gstr.Contains(err.Error(), ": ")
 		if bo {
 			msg = gstr.SubStrFromEx(err.Error(), ": ")
@@ -172,10 +186,18 @@ func MiddlewareError(r *ghttp.Request) {
 		}
 		r.Response.ClearBuffer()
 		json.Code = 0
+		if IsPanic(err) {
+			// panic记录堆栈并返回事件编号，运行时错误不向用户展示原始信息
+			id := recordIncident(r, err)
+			json.Data = IncidentData{IncidentId: id}
+			if gstr.Contains(err.Error(), "runtime error") {
+				msg = fmt.Sprintf("服务异常，请联系管理员并提供事件编号：%s", id)
+			}
+		}
 		json.Msg = msg
 		r.Response.Status = http.StatusInternalServerError
 	}
-	if r.Response.BufferLength() > 0 {
+	if r.Response.BufferLength() > 0 || status == http.StatusNotModified {
 		return
 	}
 	if status == 401 {
@@ -185,20 +207,102 @@ func MiddlewareError(r *ghttp.Request) {
 	r.Response.WriteJson(json)
 }
 
-// AuthBase 鉴权中间件，只有前端或者后端登录成功之后才能通过
+// ValidationErrorData 参数校验失败时返回的数据
+type ValidationErrorData struct {
+	Errors map[string][]string `json:"errors" dc:"字段校验错误，key为json字段名，value为错误信息列表"`
+}
+
+// ValidationErrorRes 参数校验失败的返回结构
+type ValidationErrorRes struct {
+	Code int                 `json:"code" dc:"固定为0"`
+	Data ValidationErrorData `json:"data"`
+	Msg  string              `json:"msg" dc:"第一条错误信息"`
+}
+
+// validationErrorData 将gvalid错误转换为字段错误列表，字段名使用请求结构体的json名称
+func validationErrorData(r *ghttp.Request, vErr gvalid.Error) *ValidationErrorData {
+	names := make(map[string]string)
+	if handler := r.GetServeHandler(); handler != nil && handler.Handler != nil {
+		info := handler.Handler.Info
+		if info.Type != nil && info.Type.NumIn() == 2 {
+			jsonFieldNames(info.Type.In(1), names, make(map[reflect.Type]bool))
+		}
+		// 框架解析请求时遇到第一个错误即停止校验，这里重新完整校验以返回全部字段的错误
+		if info.Type != nil && info.Type.NumIn() == 2 && info.Type.In(1).Kind() == reflect.Pointer {
+			pointer := reflect.New(info.Type.In(1).Elem()).Interface()
+			params := r.GetRequestMap()
+			if gconv.Struct(params, pointer) == nil {
+				var fullErr gvalid.Error
+				if errors.As(gvalid.New().Data(pointer).Assoc(params).Run(r.Context()), &fullErr) {
+					vErr = fullErr
+				}
+			}
+		}
+	}
+	data := &ValidationErrorData{Errors: make(map[string][]string)}
+	for _, item := range vErr.Items() {
+		for field, rules := range item {
+			if name, ok := names[field]; ok {
+				field = name
+			}
+			for _, ruleErr := range rules {
+				data.Errors[field] = append(data.Errors[field], gi18n.T(r.Context(), ruleErr.Error()))
+			}
+		}
+	}
+	return data
+}
+
+// jsonFieldNames 收集结构体（含嵌入及嵌套结构体）字段名到json名称的映射，gvalid错误只使用字段名，同名字段以外层为准
+func jsonFieldNames(t reflect.Type, names map[string]string, visited map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return
+	}
+	visited[t] = true
+	nested := make([]reflect.Type, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			jsonFieldNames(field.Type, names, visited)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if jsonName := gstr.Split(field.Tag.Get("json"), ",")[0]; jsonName != "" && jsonName != "-" {
+			if _, ok := names[field.Name]; !ok {
+				names[field.Name] = jsonName
+			}
+		}
+		nested = append(nested, field.Type)
+	}
+	for _, item := range nested {
+		jsonFieldNames(item, names, visited)
+	}
+}
+
+// AuthBase 鉴权中间件，只有前端或者后端登录成功之后才能通过，等待二次验证的会话不能通过
 func AuthBase(r *ghttp.Request, name string) {
 	info, err := r.Session.Get(name, nil)
 	if err != nil {
 		panic(err.Error())
 	}
-	if !info.IsEmpty() {
-		r.Middleware.Next()
-	} else {
+	if info.IsEmpty() {
+		// 等待二次验证的会话不持有登录信息，提示前端跳转到二次验证页
+		if IsTwoFactorPending(r, name) {
+			noTwoFactor(r)
+			return
+		}
 		NoLogin(r)
+		return
 	}
+	r.Middleware.Next()
 }
 
-// AuthAdmin 鉴权中间件，只有后端登录成功之后才能通过
+// AuthAdmin 鉴权中间件，只有后端登录成功并完成二次验证（如已开启）之后才能通过
 func AuthAdmin(r *ghttp.Request) {
 	AuthBase(r, "admin")
 }
@@ -302,11 +406,60 @@ func enhanceOpenAPIDoc(s *ghttp.Server) {
 		},
 		Version: "Api列表",
 	}
+	// 参数校验失败的返回结构，在首次请求文档时添加到各接口的400响应（接口在服务启动时才写入文档）
+	_ = openapi.Add(goai.AddInput{Object: ValidationErrorRes{}})
+	if path := s.GetOpenApiPath(); path != "" {
+		var once sync.Once
+		s.BindHookHandler(path, ghttp.HookBeforeServe, func(r *ghttp.Request) {
+			once.Do(func() {
+				addValidationResponses(openapi)
+			})
+		})
+	}
+}
+
+// addValidationResponses 为有请求参数的接口添加参数校验失败的400响应
+func addValidationResponses(openapi *goai.OpenApiV3) {
+	var ref string
+	openapi.Components.Schemas.Iterator(func(key string, _ goai.SchemaRef) bool {
+		if gstr.HasSuffix(key, "ValidationErrorRes") {
+			ref = key
+			return false
+		}
+		return true
+	})
+	if ref == "" {
+		return
+	}
+	response := goai.ResponseRef{Value: &goai.Response{
+		Description: "参数校验失败",
+		Content: goai.Content{
+			"application/json": goai.MediaType{Schema: &goai.SchemaRef{Ref: ref}},
+		},
+	}}
+	for _, path := range openapi.Paths {
+		for _, operation := range []*goai.Operation{path.Get, path.Put, path.Post, path.Delete, path.Patch, path.Head, path.Options, path.Connect, path.Trace} {
+			if operation == nil || (len(operation.Parameters) == 0 && operation.RequestBody == nil) {
+				continue
+			}
+			if _, ok := operation.Responses["400"]; !ok {
+				operation.Responses["400"] = response
+			}
+		}
+	}
 }
 
 var ConfigPath = filepath.Join(gfile.Pwd(), "manifest", "config", "config.yaml")
 var uploadPath = filepath.Join(gfile.Pwd(), "resource")
 
+// SessionIdName 会话id的cookie及请求头名称
+const SessionIdName = "zrSession"
+
+// Middlewares Start注册的全局中间件，servertest使用相同的中间件
+func Middlewares() []ghttp.HandlerFunc {
+	return []ghttp.HandlerFunc{MiddlewareError, MiddlewareMaintenance}
+}
+
 // Start 启动服务
 /*
  * @param agent string 浏览器标识
@@ -320,6 +473,10 @@ func Start(agent string, maxSessionTime time.Duration, isApi bool, maxBody ...in
 	s := g.Server()
 	s.SetDumpRouterMap(false)
 	s.AddStaticPath(fmt.Sprintf("%vstatic", gfile.Separator), uploadPath)
+	// 私有目录下的静态文件需携带签名访问
+	s.BindHookHandler("/static/*any", ghttp.HookBeforeServe, hookSignedStatic)
+	// 记录由MiddlewareError输出的响应体
+	s.BindHookHandler("/*any", ghttp.HookBeforeOutput, HookBodyLog)
 	err := s.SetLogPath(gfile.Join(gfile.Pwd(), "resource", "log"))
 	if err != nil {
 		fmt.Println(err)
@@ -338,7 +495,7 @@ func Start(agent string, maxSessionTime time.Duration, isApi bool, maxBody ...in
 	}
 	s.SetMaxHeaderBytes(1024 * 20)
 	s.SetErrorStack(true)
-	s.SetSessionIdName("zrSession")
+	s.SetSessionIdName(SessionIdName)
 	s.SetAccessLogEnabled(true)
 	s.SetSessionMaxAge(maxSessionTime)
 	err = s.SetConfigWithMap(g.Map{
@@ -348,7 +505,10 @@ func Start(agent string, maxSessionTime time.Duration, isApi bool, maxBody ...in
 	if err != nil {
 		fmt.Println(err)
 	}
-	s.Use(MiddlewareError)
+	if err = Maintenance.InitFromConfig(gctx.New()); err != nil {
+		fmt.Println(err)
+	}
+	s.Use(Middlewares()...)
 	enhanceOpenAPIDoc(s)
 	return s
 }
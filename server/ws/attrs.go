@@ -0,0 +1,240 @@
+package ws
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gogf/gf/v2/container/gvar"
+	"github.com/gogf/gf/v2/util/gconv"
+)
+
+// SetAttr 设置连接属性，如租户、角色、客户端版本，可在认证时通过Identity.Attrs设置
+/*
+ * 属性值按字符串建立索引，FindByAttrs、SendToAttrs按字符串相等匹配
+ * @param key string 属性名
+ * @param value any 属性值，为nil时删除该属性
+ */
+func (c *Connection) SetAttr(key string, value any) {
+	c.SetAttrs(map[string]any{key: value})
+}
+
+// SetAttrs 批量设置连接属性，值为nil的属性会被删除
+func (c *Connection) SetAttrs(attrs map[string]any) {
+	m := c.manager
+	m.attrMutex.Lock()
+	defer m.attrMutex.Unlock()
+	for key, value := range attrs {
+		if old, ok := c.attrs[key]; ok {
+			m.unindexAttr(c, key, old)
+			delete(c.attrs, key)
+		}
+		if value == nil {
+			continue
+		}
+		c.attrs[key] = value
+		m.indexAttr(c, key, value)
+	}
+}
+
+// DelAttr 删除连接属性
+func (c *Connection) DelAttr(keys ...string) {
+	m := c.manager
+	m.attrMutex.Lock()
+	defer m.attrMutex.Unlock()
+	for _, key := range keys {
+		if old, ok := c.attrs[key]; ok {
+			m.unindexAttr(c, key, old)
+			delete(c.attrs, key)
+		}
+	}
+}
+
+// Attr 获取连接属性，不存在时返回nil值的*gvar.Var
+func (c *Connection) Attr(key string) *gvar.Var {
+	c.manager.attrMutex.RLock()
+	defer c.manager.attrMutex.RUnlock()
+	return gvar.New(c.attrs[key])
+}
+
+// Attrs 获取连接的全部属性副本
+func (c *Connection) Attrs() map[string]any {
+	c.manager.attrMutex.RLock()
+	defer c.manager.attrMutex.RUnlock()
+	attrs := make(map[string]any, len(c.attrs))
+	for key, value := range c.attrs {
+		attrs[key] = value
+	}
+	return attrs
+}
+
+// indexAttr 将连接加入属性索引，需持有attrMutex，连接已关闭时不加入
+func (m *Manager) indexAttr(c *Connection, key string, value any) {
+	if c.ctx.Err() != nil {
+		return
+	}
+	values, ok := m.attrIndex[key]
+	if !ok {
+		values = make(map[string]map[string]*Connection)
+		m.attrIndex[key] = values
+	}
+	str := gconv.String(value)
+	conns, ok := values[str]
+	if !ok {
+		conns = make(map[string]*Connection)
+		values[str] = conns
+	}
+	conns[c.sessionID] = c
+}
+
+// unindexAttr 将连接移出属性索引，需持有attrMutex
+func (m *Manager) unindexAttr(c *Connection, key string, value any) {
+	values := m.attrIndex[key]
+	str := gconv.String(value)
+	delete(values[str], c.sessionID)
+	if len(values[str]) == 0 {
+		delete(values, str)
+	}
+	if len(values) == 0 {
+		delete(m.attrIndex, key)
+	}
+}
+
+// indexConn 连接加入管理器后建立其属性索引
+func (m *Manager) indexConn(c *Connection) {
+	m.attrMutex.Lock()
+	defer m.attrMutex.Unlock()
+	for key, value := range c.attrs {
+		m.indexAttr(c, key, value)
+	}
+}
+
+// unindexConn 连接关闭时移出属性索引
+func (m *Manager) unindexConn(c *Connection) {
+	m.attrMutex.Lock()
+	defer m.attrMutex.Unlock()
+	for key, value := range c.attrs {
+		m.unindexAttr(c, key, value)
+	}
+}
+
+// FindByAttrs 按属性查找当前节点的连接，需全部属性相等，值按字符串比较
+/*
+ * @param attrs map[string]any 属性条件，如{"tenant": 7, "role": "admin"}
+ * @return []*Connection 匹配的连接
+ */
+func (m *Manager) FindByAttrs(attrs map[string]any) []*Connection {
+	return m.findByAttrs(stringAttrs(attrs))
+}
+
+// findByAttrs 从索引中取最小的候选集合再逐一比对
+func (m *Manager) findByAttrs(attrs map[string]string) []*Connection {
+	if len(attrs) == 0 {
+		return nil
+	}
+	m.attrMutex.RLock()
+	defer m.attrMutex.RUnlock()
+	var smallest map[string]*Connection
+	for key, value := range attrs {
+		conns := m.attrIndex[key][value]
+		if len(conns) == 0 {
+			return nil
+		}
+		if smallest == nil || len(conns) < len(smallest) {
+			smallest = conns
+		}
+	}
+	result := make([]*Connection, 0, len(smallest))
+	for sessionID, conn := range smallest {
+		matched := true
+		for key, value := range attrs {
+			if _, ok := m.attrIndex[key][value][sessionID]; !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			result = append(result, conn)
+		}
+	}
+	return result
+}
+
+// SendToAttrs 发送消息到属性全部相等的连接
+/*
+ * 开启跨节点发送时同时发送到其他节点的匹配连接
+ * @param attrs map[string]any 属性条件，如{"tenant": 7, "role": "admin"}
+ * @param data []byte 消息内容
+ * @return error 发送失败时返回错误，当前节点无匹配连接且未开启跨节点发送时返回错误
+ */
+func (m *Manager) SendToAttrs(attrs map[string]any, data []byte) error {
+	if len(attrs) == 0 {
+		return errors.New("属性条件不能为空")
+	}
+	values := stringAttrs(attrs)
+	count, err := m.sendAttrsLocal(values, data)
+	if bp := m.backplane.Load(); bp != nil {
+		return errors.Join(err, bp.publish(&Envelope{Kind: envelopeAttrs, Attrs: values, Data: data}))
+	}
+	if count == 0 {
+		return errors.New("无匹配的连接")
+	}
+	return err
+}
+
+// sendAttrsLocal 发送消息到当前节点属性匹配的连接，返回连接数
+func (m *Manager) sendAttrsLocal(attrs map[string]string, data []byte) (int, error) {
+	conns := m.findByAttrs(attrs)
+	var errMsg string
+	for _, c := range conns {
+		if err := c.Send(data); err != nil {
+			errMsg += fmt.Sprintf("连接[%s]会话[%s]发送失败：%v；", c.connID, c.sessionID, err)
+		}
+	}
+	if errMsg != "" {
+		return len(conns), errors.New(errMsg)
+	}
+	return len(conns), nil
+}
+
+// BroadcastWhere 发送消息到当前节点满足条件的连接，条件可比较属性大小等索引无法处理的情况
+/*
+ * 只发送到当前节点，跨节点请使用SendToAttrs
+ * 示例：m.BroadcastWhere(func(c *Connection) bool { return c.Attr("version").Int() >= 2 }, data)
+ * @param predicate func 过滤条件，返回true时发送
+ * @param data []byte 消息内容
+ * @return int 发送的连接数
+ * @return error 发送失败时返回错误
+ */
+func (m *Manager) BroadcastWhere(predicate func(c *Connection) bool, data []byte) (int, error) {
+	m.mutex.RLock()
+	conns := make([]*Connection, 0, len(m.connections))
+	for _, conn := range m.connections {
+		conns = append(conns, conn)
+	}
+	m.mutex.RUnlock()
+
+	count := 0
+	var errMsg string
+	for _, c := range conns {
+		if !predicate(c) {
+			continue
+		}
+		count++
+		if err := c.Send(data); err != nil {
+			errMsg += fmt.Sprintf("连接[%s]会话[%s]发送失败：%v；", c.connID, c.sessionID, err)
+		}
+	}
+	if errMsg != "" {
+		return count, errors.New(errMsg)
+	}
+	return count, nil
+}
+
+// stringAttrs 属性值转为字符串，与索引保持一致
+func stringAttrs(attrs map[string]any) map[string]string {
+	values := make(map[string]string, len(attrs))
+	for key, value := range attrs {
+		values[key] = gconv.String(value)
+	}
+	return values
+}
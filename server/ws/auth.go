@@ -0,0 +1,198 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/net/ghttp"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/gorilla/websocket"
+)
+
+// 关闭码，4000-4999为应用自定义
+const (
+	CloseTokenExpired = 4001 // 凭证已过期，客户端应刷新凭证后重连
+)
+
+// ProtocolTokenName 通过Sec-WebSocket-Protocol传递凭证时的子协议名
+/*
+ * 浏览器无法为WebSocket设置请求头，可将凭证作为子协议传递：
+ *	new WebSocket(url, ["access_token", token])
+ * 服务端回应子协议access_token
+ */
+const ProtocolTokenName = "access_token"
+
+// Identity 认证通过的身份
+type Identity struct {
+	ConnID   string         // 连接ID（如用户ID）
+	ExpireAt time.Time      // 凭证过期时间，到期后以CloseTokenExpired关闭连接，零值表示不过期
+	Protocol string         // 需要回应的子协议，通过Sec-WebSocket-Protocol认证时必须回应
+	Attrs    map[string]any // 连接属性，如租户、角色，见Connection.SetAttr
+}
+
+// Authenticator 升级前的认证函数，返回*AuthError时按其Status响应
+type Authenticator func(r *http.Request) (*Identity, error)
+
+// AuthError 认证失败
+type AuthError struct {
+	Status int    // 响应的http状态码
+	Msg    string // 错误信息
+}
+
+// Error 实现error接口
+func (e *AuthError) Error() string {
+	return e.Msg
+}
+
+var (
+	ErrUnauthorized = &AuthError{Status: http.StatusUnauthorized, Msg: "未登录或凭证无效"}
+	ErrForbidden    = &AuthError{Status: http.StatusForbidden, Msg: "无权建立连接"}
+)
+
+// authenticate 执行认证，失败时响应http状态码
+func (m *Manager) authenticate(w http.ResponseWriter, r *http.Request, connID string) (*Identity, error) {
+	if m.Authenticator == nil {
+		return &Identity{ConnID: connID}, nil
+	}
+	identity, err := m.Authenticator(r)
+	if err == nil && (identity == nil || identity.ConnID == "") {
+		err = ErrUnauthorized
+	}
+	if err == nil && connID != "" && connID != identity.ConnID {
+		err = ErrForbidden
+	}
+	if err == nil && !identity.ExpireAt.IsZero() && !identity.ExpireAt.After(time.Now()) {
+		err = &AuthError{Status: http.StatusUnauthorized, Msg: "凭证已过期"}
+	}
+	if err != nil {
+		var authErr *AuthError
+		if !errors.As(err, &authErr) {
+			authErr = &AuthError{Status: http.StatusUnauthorized, Msg: err.Error()}
+		}
+		http.Error(w, authErr.Msg, authErr.Status)
+		return nil, authErr
+	}
+	return identity, nil
+}
+
+// Accept 使用Authenticator认证并升级连接，连接ID取自认证结果
+/*
+ * 认证失败时已响应对应的http状态码，调用方无需再写入响应
+ */
+func (m *Manager) Accept(w http.ResponseWriter, r *http.Request) (*Connection, error) {
+	if m.Authenticator == nil {
+		http.Error(w, "未设置认证函数", http.StatusInternalServerError)
+		return nil, errors.New("未设置认证函数")
+	}
+	return m.Upgrade(w, r, "")
+}
+
+// SetExpire 更新凭证过期时间，如客户端刷新了凭证，零值表示不再过期
+func (c *Connection) SetExpire(expireAt time.Time) {
+	c.expireMutex.Lock()
+	defer c.expireMutex.Unlock()
+	if c.expireTimer != nil {
+		c.expireTimer.Stop()
+		c.expireTimer = nil
+	}
+	if expireAt.IsZero() || c.ctx.Err() != nil {
+		return
+	}
+	c.expireTimer = time.AfterFunc(time.Until(expireAt), func() {
+		c.CloseWithCode(CloseTokenExpired, errors.New("凭证已过期"))
+	})
+}
+
+// stopExpire 连接关闭时停止过期计时
+func (c *Connection) stopExpire() {
+	c.expireMutex.Lock()
+	defer c.expireMutex.Unlock()
+	if c.expireTimer != nil {
+		c.expireTimer.Stop()
+		c.expireTimer = nil
+	}
+}
+
+// BearerToken 获取请求携带的凭证，依次读取Authorization: Bearer请求头、access_token查询参数
+func BearerToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return r.URL.Query().Get(ProtocolTokenName)
+}
+
+// ProtocolToken 获取通过Sec-WebSocket-Protocol传递的凭证，格式为"access_token, <凭证>"
+/*
+ * @return token string 凭证
+ * @return protocol string 需要回应的子协议
+ */
+func ProtocolToken(r *http.Request) (token string, protocol string) {
+	protocols := websocket.Subprotocols(r)
+	for i, name := range protocols {
+		if name == ProtocolTokenName && i+1 < len(protocols) {
+			return protocols[i+1], ProtocolTokenName
+		}
+	}
+	return "", ""
+}
+
+// TokenAuthenticator 凭证认证，凭证依次取自Authorization请求头、access_token查询参数、Sec-WebSocket-Protocol
+/*
+ * @param verify func 校验凭证并返回身份，凭证无效时返回错误
+ * @return Authenticator 认证函数
+ */
+func TokenAuthenticator(verify func(ctx context.Context, token string) (*Identity, error)) Authenticator {
+	return func(r *http.Request) (*Identity, error) {
+		token, protocol := BearerToken(r), ""
+		if token == "" {
+			token, protocol = ProtocolToken(r)
+		}
+		if token == "" {
+			return nil, ErrUnauthorized
+		}
+		identity, err := verify(r.Context(), token)
+		if err != nil {
+			return nil, err
+		}
+		if identity != nil && protocol != "" {
+			identity.Protocol = protocol
+		}
+		return identity, nil
+	}
+}
+
+// SessionAuthenticator 使用登录session认证，需在gf路由中调用Upgrade/Accept并传入r.Request
+/*
+ * 等待二次验证的会话不持有登录信息，不能通过
+ * 未登录或登录信息中没有idField时返回401，会话存储读取失败时返回500
+ * @param sessionKey string 登录信息的session键，如admin、user
+ * @param idField string 登录信息中作为连接ID的字段，如id
+ * @return Authenticator 认证函数
+ */
+func SessionAuthenticator(sessionKey string, idField string) Authenticator {
+	return func(r *http.Request) (*Identity, error) {
+		request := ghttp.RequestFromCtx(r.Context())
+		if request == nil {
+			return nil, &AuthError{Status: http.StatusInternalServerError, Msg: "无法获取会话，请在gf路由中升级连接"}
+		}
+		info, err := request.Session.Get(sessionKey)
+		if err != nil {
+			// 会话存储故障不是未登录，返回500，避免客户端误以为已退出登录
+			log.Printf("[认证] 读取会话失败：%v", err)
+			return nil, &AuthError{Status: http.StatusInternalServerError, Msg: "读取会话失败"}
+		}
+		// 等待二次验证的会话不持有登录信息
+		if info.IsEmpty() {
+			return nil, ErrUnauthorized
+		}
+		connID := gconv.String(info.Map()[idField])
+		if connID == "" {
+			return nil, ErrUnauthorized
+		}
+		return &Identity{ConnID: connID}, nil
+	}
+}
@@ -0,0 +1,360 @@
+package ws
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+// 节点间消息类型
+const (
+	envelopeConn      = "conn"      // 定向发送
+	envelopeSession   = "session"   // 发送到单个会话
+	envelopeRoom      = "room"      // 房间广播
+	envelopeBroadcast = "broadcast" // 全局广播
+	envelopeAttrs     = "attrs"     // 按属性发送
+	envelopeOnline    = "online"    // connID在节点上线
+	envelopeOffline   = "offline"   // connID在节点的最后一个连接关闭
+	envelopeSync      = "sync"      // 节点的全部connID，定时发送
+	envelopeHello     = "hello"     // 节点加入，其他节点收到后立即发送sync
+	envelopeBye       = "bye"       // 节点退出
+)
+
+// DefaultPresenceInterval 默认在线状态同步间隔
+const DefaultPresenceInterval = 30 * time.Second
+
+// Envelope 节点间传递的消息
+type Envelope struct {
+	Node    string            `json:"node"`              // 发送节点
+	Kind    string            `json:"kind"`              // 消息类型
+	Target  string            `json:"target,omitempty"`  // 连接ID、会话ID或房间名
+	Exclude []string          `json:"exclude,omitempty"` // 房间广播排除的连接ID
+	Data    []byte            `json:"data,omitempty"`    // 发送给客户端的消息
+	ConnIDs []string          `json:"connIds,omitempty"` // sync消息携带的全部connID
+	Attrs   map[string]string `json:"attrs,omitempty"`   // 按属性发送的属性条件
+}
+
+// Backplane 节点间的发布订阅通道，用于多实例部署时跨节点发送消息
+type Backplane interface {
+	// Publish 发布消息到所有节点，发布节点自身收到时会被忽略
+	Publish(ctx context.Context, envelope *Envelope) error
+	// Subscribe 订阅其他节点的消息
+	Subscribe(ctx context.Context, handler func(envelope *Envelope)) error
+	// Close 关闭通道
+	Close() error
+}
+
+// BackplaneOption 跨节点配置
+type BackplaneOption struct {
+	Node             string        // 节点ID，默认随机生成
+	Backplane        Backplane     // 发布订阅通道
+	PresenceInterval time.Duration // 在线状态同步间隔，超过3个间隔未收到同步的节点视为下线，默认30秒
+}
+
+// NodeInfo 节点信息
+type NodeInfo struct {
+	Node     string    `json:"node" dc:"节点ID"`
+	Count    int       `json:"count" dc:"节点上在线的连接ID数"`
+	LastSeen time.Time `json:"lastSeen" dc:"最近一次收到该节点同步的时间"`
+	Local    bool      `json:"local" dc:"是否为当前节点"`
+}
+
+// nodePresence 节点的在线状态
+type nodePresence struct {
+	connIDs  map[string]struct{}
+	lastSeen time.Time
+}
+
+// backplane 跨节点状态
+type backplane struct {
+	option   BackplaneOption
+	manager  *Manager
+	nodes    map[string]*nodePresence // 其他节点的在线状态
+	mutex    sync.RWMutex             // 保护nodes
+	presence chan *Envelope           // 本节点待发布的上下线消息，保证顺序
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+// UseBackplane 开启跨节点发送，需在Upgrade之前调用
+/*
+ * 开启后SendToConn、SendToSession、Broadcast、BroadcastToRoom、SendToAttrs会同时发送到其他节点的连接，
+ * 各节点定时同步在线的connID，可通过Presence查询connID所在节点
+ * @param option *BackplaneOption 跨节点配置
+ * @return error 订阅失败时返回错误
+ */
+func (m *Manager) UseBackplane(option *BackplaneOption) error {
+	if option == nil || option.Backplane == nil {
+		return errors.New("发布订阅通道不能为空")
+	}
+	if m.backplane.Load() != nil {
+		return errors.New("已开启跨节点发送")
+	}
+	bp := &backplane{
+		option:   *option,
+		manager:  m,
+		nodes:    make(map[string]*nodePresence),
+		presence: make(chan *Envelope, 1024),
+	}
+	if bp.option.Node == "" {
+		bp.option.Node = guid.S()
+	}
+	if bp.option.PresenceInterval <= 0 {
+		bp.option.PresenceInterval = DefaultPresenceInterval
+	}
+	bp.ctx, bp.cancel = context.WithCancel(context.Background())
+	if err := bp.option.Backplane.Subscribe(bp.ctx, bp.receive); err != nil {
+		bp.cancel()
+		return err
+	}
+	m.backplane.Store(bp)
+	go bp.loop()
+	return nil
+}
+
+// StopBackplane 通知其他节点本节点退出并关闭发布订阅通道
+func (m *Manager) StopBackplane() error {
+	bp := m.backplane.Swap(nil)
+	if bp == nil {
+		return nil
+	}
+	_ = bp.publish(&Envelope{Kind: envelopeBye})
+	bp.cancel()
+	return bp.option.Backplane.Close()
+}
+
+// Node 当前节点ID，未开启跨节点发送时为空
+func (m *Manager) Node() string {
+	if bp := m.backplane.Load(); bp != nil {
+		return bp.option.Node
+	}
+	return ""
+}
+
+// Presence 获取connID所在的节点，包括当前节点，按节点ID排序，未开启跨节点发送时返回nil
+func (m *Manager) Presence(connID string) []string {
+	bp := m.backplane.Load()
+	if bp == nil {
+		return nil
+	}
+	nodes := bp.remoteNodes(connID)
+	if m.IsOnline(connID) {
+		nodes = append(nodes, bp.option.Node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// IsClusterOnline 判断connID是否在任一节点在线，未开启跨节点发送时同IsOnline
+func (m *Manager) IsClusterOnline(connID string) bool {
+	if m.IsOnline(connID) {
+		return true
+	}
+	bp := m.backplane.Load()
+	return bp != nil && len(bp.remoteNodes(connID)) > 0
+}
+
+// Nodes 获取所有节点，按节点ID排序
+func (m *Manager) Nodes() []NodeInfo {
+	bp := m.backplane.Load()
+	if bp == nil {
+		return nil
+	}
+	infos := []NodeInfo{{Node: bp.option.Node, Count: m.GetUserCount(), LastSeen: time.Now(), Local: true}}
+	bp.mutex.RLock()
+	for node, presence := range bp.nodes {
+		infos = append(infos, NodeInfo{Node: node, Count: len(presence.connIDs), LastSeen: presence.lastSeen})
+	}
+	bp.mutex.RUnlock()
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Node < infos[j].Node
+	})
+	return infos
+}
+
+// presenceChanged 本节点connID上线或全部下线
+func (m *Manager) presenceChanged(connID string, online bool) {
+	bp := m.backplane.Load()
+	if bp == nil {
+		return
+	}
+	kind := envelopeOffline
+	if online {
+		kind = envelopeOnline
+	}
+	select {
+	case bp.presence <- &Envelope{Kind: kind, Target: connID}:
+	default:
+		// 队列已满时丢弃，定时同步会修正
+	}
+}
+
+// localConnIDs 本节点在线的connID
+func (m *Manager) localConnIDs() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	connIDs := make([]string, 0, len(m.users))
+	for connID := range m.users {
+		connIDs = append(connIDs, connID)
+	}
+	return connIDs
+}
+
+// publish 发布消息
+func (b *backplane) publish(envelope *Envelope) error {
+	envelope.Node = b.option.Node
+	return b.option.Backplane.Publish(b.ctx, envelope)
+}
+
+// loop 发布上下线消息并定时同步、清理过期节点
+func (b *backplane) loop() {
+	ticker := time.NewTicker(b.option.PresenceInterval)
+	defer ticker.Stop()
+	b.sync(envelopeHello)
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case envelope := <-b.presence:
+			if err := b.publish(envelope); err != nil {
+				log.Printf("[跨节点] 发布上下线消息失败：%v", err)
+			}
+		case <-ticker.C:
+			b.sync(envelopeSync)
+			b.expire()
+		}
+	}
+}
+
+// sync 发布本节点的全部connID
+func (b *backplane) sync(kind string) {
+	if err := b.publish(&Envelope{Kind: kind, ConnIDs: b.manager.localConnIDs()}); err != nil {
+		log.Printf("[跨节点] 同步在线状态失败：%v", err)
+	}
+}
+
+// expire 清理超过3个同步间隔未收到消息的节点
+func (b *backplane) expire() {
+	deadline := time.Now().Add(-3 * b.option.PresenceInterval)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for node, presence := range b.nodes {
+		if presence.lastSeen.Before(deadline) {
+			delete(b.nodes, node)
+		}
+	}
+}
+
+// receive 处理其他节点的消息
+func (b *backplane) receive(envelope *Envelope) {
+	// 已调用StopBackplane，共享的通道可能仍会投递消息
+	if b.ctx.Err() != nil || envelope == nil || envelope.Node == "" || envelope.Node == b.option.Node {
+		return
+	}
+	m := b.manager
+	switch envelope.Kind {
+	case envelopeConn:
+		_, _ = m.sendLocal(envelope.Target, envelope.Data)
+	case envelopeSession:
+		if conn := m.GetSession(envelope.Target); conn != nil {
+			_ = conn.Send(envelope.Data)
+		}
+	case envelopeRoom:
+		_ = m.broadcastRoomLocal(envelope.Target, envelope.Data, envelope.Exclude)
+	case envelopeBroadcast:
+		_, _ = m.broadcastLocal(envelope.Data)
+	case envelopeAttrs:
+		_, _ = m.sendAttrsLocal(envelope.Attrs, envelope.Data)
+	case envelopeOnline, envelopeOffline:
+		b.mutex.Lock()
+		presence := b.node(envelope.Node)
+		if envelope.Kind == envelopeOnline {
+			presence.connIDs[envelope.Target] = struct{}{}
+		} else {
+			delete(presence.connIDs, envelope.Target)
+		}
+		b.mutex.Unlock()
+	case envelopeSync, envelopeHello:
+		connIDs := make(map[string]struct{}, len(envelope.ConnIDs))
+		for _, connID := range envelope.ConnIDs {
+			connIDs[connID] = struct{}{}
+		}
+		b.mutex.Lock()
+		b.node(envelope.Node).connIDs = connIDs
+		b.mutex.Unlock()
+		if envelope.Kind == envelopeHello {
+			// 新节点加入，立即同步本节点的在线状态
+			go b.sync(envelopeSync)
+		}
+	case envelopeBye:
+		b.mutex.Lock()
+		delete(b.nodes, envelope.Node)
+		b.mutex.Unlock()
+	}
+}
+
+// node 获取节点的在线状态并更新时间，需持有mutex
+func (b *backplane) node(node string) *nodePresence {
+	presence, ok := b.nodes[node]
+	if !ok {
+		presence = &nodePresence{connIDs: make(map[string]struct{})}
+		b.nodes[node] = presence
+	}
+	presence.lastSeen = time.Now()
+	return presence
+}
+
+// remoteNodes connID所在的其他节点
+func (b *backplane) remoteNodes(connID string) []string {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	var nodes []string
+	for node, presence := range b.nodes {
+		if _, ok := presence.connIDs[connID]; ok {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// MemoryBackplane 内存发布订阅通道，同一进程内的多个Manager共享，用于测试
+type MemoryBackplane struct {
+	handlers []func(envelope *Envelope)
+	mutex    sync.RWMutex
+}
+
+// NewMemoryBackplane 创建内存发布订阅通道
+func NewMemoryBackplane() *MemoryBackplane {
+	return &MemoryBackplane{}
+}
+
+// Publish 同步发送到所有订阅者
+func (b *MemoryBackplane) Publish(ctx context.Context, envelope *Envelope) error {
+	b.mutex.RLock()
+	handlers := append([]func(envelope *Envelope){}, b.handlers...)
+	b.mutex.RUnlock()
+	for _, handler := range handlers {
+		// 复制一份，模拟跨进程传递
+		copied := *envelope
+		handler(&copied)
+	}
+	return nil
+}
+
+// Subscribe 添加订阅者
+func (b *MemoryBackplane) Subscribe(ctx context.Context, handler func(envelope *Envelope)) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.handlers = append(b.handlers, handler)
+	return nil
+}
+
+// Close 内存通道由多个Manager共享，不做处理
+func (b *MemoryBackplane) Close() error {
+	return nil
+}
@@ -0,0 +1,123 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/black1552/base-common/mqtt/client"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// DefaultMqttPublishQueueSize 默认MQTT待发布消息数
+const DefaultMqttPublishQueueSize = 1024
+
+var (
+	ErrBackplaneQueueFull = errors.New("跨节点发送队列已满")
+	ErrBackplaneClosed    = errors.New("跨节点发送通道已关闭")
+)
+
+// MqttBackplane 基于MQTT的发布订阅通道
+/*
+ * 所有节点使用同一主题，client.Client按主题分发回调，可与业务共用同一客户端
+ * Publish只将消息放入发送队列，由单独的协程发布，不阻塞SendToConn、Broadcast等调用
+ */
+type MqttBackplane struct {
+	client    *client.Client
+	topic     string
+	qos       byte
+	queue     chan []byte   // 待发布的消息
+	done      chan struct{} // 关闭信号
+	stopped   chan struct{} // 发布协程已退出
+	closeOnce sync.Once
+}
+
+// NewMqttBackplane 创建MQTT发布订阅通道
+/*
+ * @param c *client.Client 已连接的MQTT客户端
+ * @param topic string 节点间通信的主题，如"app/ws/backplane"
+ * @param qos byte 服务质量，建议1
+ */
+func NewMqttBackplane(c *client.Client, topic string, qos byte) *MqttBackplane {
+	b := &MqttBackplane{
+		client:  c,
+		topic:   topic,
+		qos:     qos,
+		queue:   make(chan []byte, DefaultMqttPublishQueueSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go b.publishLoop()
+	return b
+}
+
+// Publish 将消息放入发送队列，队列已满时返回ErrBackplaneQueueFull
+func (b *MqttBackplane) Publish(ctx context.Context, envelope *Envelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-b.done:
+		return ErrBackplaneClosed
+	default:
+	}
+	select {
+	case b.queue <- payload:
+		return nil
+	default:
+		return ErrBackplaneQueueFull
+	}
+}
+
+// publishLoop 按顺序发布队列中的消息（持续运行），关闭时发布完已入队的消息再退出
+func (b *MqttBackplane) publishLoop() {
+	defer close(b.stopped)
+	for {
+		select {
+		case payload := <-b.queue:
+			b.publish(payload)
+		case <-b.done:
+			for {
+				select {
+				case payload := <-b.queue:
+					b.publish(payload)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// publish 发布消息，失败时记录日志
+func (b *MqttBackplane) publish(payload []byte) {
+	if err := b.client.Publish(b.topic, b.qos, false, payload); err != nil {
+		log.Printf("[跨节点] 发布MQTT消息失败：%v", err)
+	}
+}
+
+// Subscribe 订阅主题
+func (b *MqttBackplane) Subscribe(ctx context.Context, handler func(envelope *Envelope)) error {
+	return b.client.Subscribe(b.topic, b.qos, func(_ mqtt.Client, msg mqtt.Message) {
+		var envelope Envelope
+		if err := json.Unmarshal(msg.Payload(), &envelope); err != nil {
+			log.Printf("[跨节点] 解析MQTT消息失败：%v", err)
+			return
+		}
+		handler(&envelope)
+	})
+}
+
+// Close 停止发布并取消订阅主题，MQTT客户端由调用方管理，不会断开
+func (b *MqttBackplane) Close() error {
+	var err error
+	b.closeOnce.Do(func() {
+		close(b.done)
+		<-b.stopped
+		err = b.client.Unsubscribe(b.topic)
+	})
+	return err
+}
@@ -1,6 +1,8 @@
 package ws
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"time"
@@ -41,8 +43,8 @@ func NewWs() *Manager {
 	}
 
 	// 连接断开回调
-	m.OnDisconnect = func(connID string, err error) {
-		log.Printf("业务回调：连接[%s]下线，原因：%v，当前在线数：%d", connID, err, m.GetOnlineCount())
+	m.OnDisconnect = func(connID string, err error, last bool) {
+		log.Printf("业务回调：连接[%s]下线，原因：%v，当前在线数：%d，是否全部下线：%v", connID, err, m.GetOnlineCount(), last)
 	}
 	return m
 }
@@ -50,7 +52,11 @@ func Upgrade(w http.ResponseWriter, r *http.Request, connID string) {
 	_, err := manager.Upgrade(w, r, connID)
 	if err != nil {
 		log.Printf("升级连接失败：%v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		// 认证失败时已响应401/403
+		var authErr *AuthError
+		if !errors.As(err, &authErr) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 }
@@ -67,6 +73,21 @@ func main() {
 		Upgrade(w, r, connID)
 	})
 
+	// 需认证的WebSocket路由，连接ID取自凭证
+	// 客户端：new WebSocket("ws://localhost:8080/ws/auth", ["access_token", token])
+	authManager := NewWs()
+	authManager.Authenticator = TokenAuthenticator(func(ctx context.Context, token string) (*Identity, error) {
+		if token != "demo-token" {
+			return nil, ErrUnauthorized
+		}
+		return &Identity{ConnID: "demo", ExpireAt: time.Now().Add(time.Hour)}, nil
+	})
+	http.HandleFunc("/ws/auth", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := authManager.Accept(w, r); err != nil {
+			log.Printf("认证连接失败：%v", err)
+		}
+	})
+
 	// 5. 启动服务
 	log.Println("WebSocket服务启动：http://localhost:8080/ws")
 	log.Fatal(http.ListenAndServe(":8080", nil))
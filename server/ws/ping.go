@@ -0,0 +1,79 @@
+package ws
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// RTT 最近一次ping/pong的往返时间，未开启ServerPing或尚未收到pong时为0
+func (c *Connection) RTT() time.Duration {
+	return time.Duration(c.rtt.Load())
+}
+
+// LastPong 最近一次收到pong的时间
+func (c *Connection) LastPong() time.Time {
+	if nano := c.lastPong.Load(); nano > 0 {
+		return time.Unix(0, nano)
+	}
+	return time.Time{}
+}
+
+// handlePong 收到pong：记录往返时间，重置未响应次数并延长读超时
+func (c *Connection) handlePong(appData string) error {
+	now := time.Now()
+	if sent, err := strconv.ParseInt(appData, 10, 64); err == nil && sent > 0 {
+		c.rtt.Store(now.UnixNano() - sent)
+	}
+	c.lastPong.Store(now.UnixNano())
+	c.heartbeatRetry.Store(0)
+	c.resetHeartbeat()
+	return c.conn.SetReadDeadline(now.Add(c.manager.config.ReadTimeout))
+}
+
+// resetHeartbeat 收到心跳后重新开始心跳超时计时
+func (c *Connection) resetHeartbeat() {
+	if entry := c.heartbeatTime.Load(); entry != nil {
+		entry.Reset()
+	}
+}
+
+// stopHeartbeat 停止心跳超时计时，可重复调用
+func (c *Connection) stopHeartbeat() {
+	if entry := c.heartbeatTime.Swap(nil); entry != nil {
+		entry.Close()
+	}
+}
+
+// pingLoop 按HeartbeatInterval发送ping控制帧，连续HeartbeatMaxRetry次未收到pong时关闭连接
+func (c *Connection) pingLoop() {
+	config := c.manager.config
+	ticker := time.NewTicker(config.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			// 上一次ping之后未收到pong
+			if c.lastPong.Load() < c.lastPing.Load() {
+				if retry := c.heartbeatRetry.Add(1); int(retry) >= config.HeartbeatMaxRetry {
+					log.Printf("[心跳检测] 连接[%s]连续%d次未响应ping", c.connID, retry)
+					c.Close(fmt.Errorf("心跳超时：连续%d次未响应ping", retry))
+					return
+				}
+			}
+			now := time.Now()
+			c.lastPing.Store(now.UnixNano())
+			// WriteControl可与WritePump并发调用
+			err := c.conn.WriteControl(websocket.PingMessage, []byte(strconv.FormatInt(now.UnixNano(), 10)), now.Add(config.WriteTimeout))
+			if err != nil {
+				c.Close(fmt.Errorf("发送ping失败：%w", err))
+				return
+			}
+		}
+	}
+}
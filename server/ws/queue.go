@@ -0,0 +1,135 @@
+package ws
+
+import (
+	"errors"
+	"time"
+)
+
+// OverflowPolicy 发送队列已满时的处理策略
+type OverflowPolicy string
+
+const (
+	OverflowBlock      OverflowPolicy = "block"      // 阻塞等待，超过EnqueueTimeout返回ErrQueueFull
+	OverflowDropOldest OverflowPolicy = "dropOldest" // 丢弃队列中最早的消息
+	OverflowDropNewest OverflowPolicy = "dropNewest" // 丢弃本次发送的消息
+	OverflowDisconnect OverflowPolicy = "disconnect" // 断开消费过慢的连接
+)
+
+// 默认发送队列配置
+const (
+	DefaultSendQueueSize  = 256
+	DefaultWriteBatchSize = 16
+)
+
+var (
+	ErrConnClosed = errors.New("连接已关闭，无法发送消息")
+	ErrQueueFull  = errors.New("发送队列已满")
+	ErrDropped    = errors.New("发送队列已满，消息被丢弃")
+)
+
+// outbound 待发送的消息
+type outbound struct {
+	msgType int
+	data    []byte
+	result  chan error // 发送结果，缓冲1
+}
+
+// done 通知发送结果
+func (o *outbound) done(err error) {
+	if o.result != nil {
+		o.result <- err
+	}
+}
+
+// SendAsync 将消息放入发送队列，返回发送结果通道
+/*
+ * 消息写入客户端后通道收到nil，被丢弃、连接关闭或写入失败时收到对应错误，通道只会收到一次结果
+ * @param data []byte 消息内容
+ * @return <-chan error 发送结果
+ */
+func (c *Connection) SendAsync(data []byte) <-chan error {
+	item := &outbound{msgType: c.manager.config.MsgType, data: data, result: make(chan error, 1)}
+	if err := c.enqueue(item); err != nil {
+		item.done(err)
+	}
+	return item.result
+}
+
+// enqueue 按队列溢出策略放入发送队列
+func (c *Connection) enqueue(item *outbound) error {
+	c.queueMutex.RLock()
+	defer c.queueMutex.RUnlock()
+	if c.queueClosed {
+		return ErrConnClosed
+	}
+	select {
+	case c.queue <- item:
+		return nil
+	default:
+	}
+	config := c.manager.config
+	switch config.OverflowPolicy {
+	case OverflowBlock:
+		timer := time.NewTimer(config.EnqueueTimeout)
+		defer timer.Stop()
+		select {
+		case c.queue <- item:
+			return nil
+		case <-c.ctx.Done():
+			return ErrConnClosed
+		case <-timer.C:
+			return ErrQueueFull
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case oldest := <-c.queue:
+				oldest.done(ErrDropped)
+			default:
+			}
+			select {
+			case c.queue <- item:
+				return nil
+			default:
+			}
+		}
+	case OverflowDropNewest:
+		return ErrDropped
+	default:
+		// 在新协程中关闭，避免在持有队列锁时关闭连接
+		go c.Close(errors.New("发送队列已满，客户端消费过慢"))
+		return ErrQueueFull
+	}
+}
+
+// closeQueue 关闭发送队列，队列中未发送的消息返回ErrConnClosed
+func (c *Connection) closeQueue() {
+	c.queueMutex.Lock()
+	c.queueClosed = true
+	c.queueMutex.Unlock()
+	for {
+		select {
+		case item := <-c.queue:
+			item.done(ErrConnClosed)
+		default:
+			return
+		}
+	}
+}
+
+// writeBatch 批量写入消息，写入失败时剩余消息返回同一错误
+func (c *Connection) writeBatch(batch []*outbound) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.manager.config.WriteTimeout))
+	for i, item := range batch {
+		if err := c.conn.WriteMessage(item.msgType, item.data); err != nil {
+			for _, rest := range batch[i:] {
+				rest.done(err)
+			}
+			return err
+		}
+		item.done(nil)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// RoomInfo 房间信息
+type RoomInfo struct {
+	Room  string `json:"room" dc:"房间名"`
+	Count int    `json:"count" dc:"房间内连接数"`
+}
+
+// JoinRoom 将connID的所有连接加入房间，连接关闭时自动退出所有房间，只加入单个连接请使用Connection.JoinRoom
+/*
+ * @param connID string 连接ID
+ * @param room string 房间名
+ * @return error 连接不存在或已关闭时返回错误
+ */
+func (m *Manager) JoinRoom(connID string, room string) error {
+	if room == "" {
+		return errors.New("房间名不能为空")
+	}
+	conns := m.connsOf(connID)
+	if len(conns) == 0 {
+		return fmt.Errorf("连接[%s]不存在", connID)
+	}
+	m.joinRoom(conns, room)
+	return nil
+}
+
+// JoinRoom 将当前连接加入房间，同一connID的其他连接不受影响
+func (c *Connection) JoinRoom(room string) error {
+	if room == "" {
+		return errors.New("房间名不能为空")
+	}
+	c.manager.joinRoom([]*Connection{c}, room)
+	return nil
+}
+
+// joinRoom 将连接加入房间
+func (m *Manager) joinRoom(conns []*Connection, room string) {
+	joined := make([]*Connection, 0, len(conns))
+	m.roomMutex.Lock()
+	for _, conn := range conns {
+		// 连接关闭时先取消上下文再退出房间，这里检查可避免已关闭的连接残留在房间中
+		if conn.ctx.Err() != nil {
+			continue
+		}
+		if _, ok := conn.rooms[room]; ok {
+			continue
+		}
+		members, ok := m.rooms[room]
+		if !ok {
+			members = make(map[string]*Connection)
+			m.rooms[room] = members
+		}
+		members[conn.sessionID] = conn
+		conn.rooms[room] = struct{}{}
+		joined = append(joined, conn)
+	}
+	m.roomMutex.Unlock()
+
+	if m.OnJoinRoom != nil {
+		for _, conn := range joined {
+			m.OnJoinRoom(conn.connID, room)
+		}
+	}
+}
+
+// LeaveRoom 将connID的所有连接移出房间，连接不在房间中时不做处理
+/*
+ * @param connID string 连接ID
+ * @param room string 房间名
+ */
+func (m *Manager) LeaveRoom(connID string, room string) {
+	m.leaveRoom(m.connsOf(connID), room)
+}
+
+// LeaveRoom 将当前连接移出房间，同一connID的其他连接不受影响
+func (c *Connection) LeaveRoom(room string) {
+	c.manager.leaveRoom([]*Connection{c}, room)
+}
+
+// leaveRoom 将连接移出房间
+func (m *Manager) leaveRoom(conns []*Connection, room string) {
+	left := make([]*Connection, 0, len(conns))
+	m.roomMutex.Lock()
+	for _, conn := range conns {
+		if m.removeFromRoom(conn, room) {
+			left = append(left, conn)
+		}
+	}
+	m.roomMutex.Unlock()
+	if m.OnLeaveRoom != nil {
+		for _, conn := range left {
+			m.OnLeaveRoom(conn.connID, room)
+		}
+	}
+}
+
+// leaveAllRooms 连接关闭时退出所有房间
+func (m *Manager) leaveAllRooms(conn *Connection) {
+	m.roomMutex.Lock()
+	rooms := make([]string, 0, len(conn.rooms))
+	for room := range conn.rooms {
+		m.removeFromRoom(conn, room)
+		rooms = append(rooms, room)
+	}
+	m.roomMutex.Unlock()
+	if m.OnLeaveRoom != nil {
+		for _, room := range rooms {
+			m.OnLeaveRoom(conn.connID, room)
+		}
+	}
+}
+
+// removeFromRoom 移出房间，房间为空时删除，需持有roomMutex
+func (m *Manager) removeFromRoom(conn *Connection, room string) bool {
+	if _, ok := conn.rooms[room]; !ok {
+		return false
+	}
+	delete(conn.rooms, room)
+	if members, ok := m.rooms[room]; ok {
+		delete(members, conn.sessionID)
+		if len(members) == 0 {
+			delete(m.rooms, room)
+		}
+	}
+	return true
+}
+
+// BroadcastToRoom 广播消息到房间内的所有连接，开启跨节点发送时同时发送到其他节点
+/*
+ * @param room string 房间名
+ * @param data []byte 消息内容
+ * @param exclude ...string 不发送的连接ID，如消息发送者
+ * @return error 发送失败的连接信息
+ */
+func (m *Manager) BroadcastToRoom(room string, data []byte, exclude ...string) error {
+	err := m.broadcastRoomLocal(room, data, exclude)
+	if bp := m.backplane.Load(); bp != nil {
+		err = errors.Join(err, bp.publish(&Envelope{Kind: envelopeRoom, Target: room, Exclude: exclude, Data: data}))
+	}
+	return err
+}
+
+// broadcastRoomLocal 广播消息到当前节点房间内的连接
+func (m *Manager) broadcastRoomLocal(room string, data []byte, exclude []string) error {
+	m.roomMutex.RLock()
+	conns := make([]*Connection, 0, len(m.rooms[room]))
+	for _, conn := range m.rooms[room] {
+		if !containsString(exclude, conn.connID) {
+			conns = append(conns, conn)
+		}
+	}
+	m.roomMutex.RUnlock()
+
+	var errMsg string
+	for _, c := range conns {
+		if err := c.Send(data); err != nil {
+			errMsg += fmt.Sprintf("连接[%s]房间[%s]广播失败：%v；", c.connID, room, err)
+		}
+	}
+	if errMsg != "" {
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+// RoomMembers 获取房间内的连接ID（去重），按字母排序
+func (m *Manager) RoomMembers(room string) []string {
+	m.roomMutex.RLock()
+	seen := make(map[string]struct{}, len(m.rooms[room]))
+	members := make([]string, 0, len(m.rooms[room]))
+	for _, conn := range m.rooms[room] {
+		if _, ok := seen[conn.connID]; !ok {
+			seen[conn.connID] = struct{}{}
+			members = append(members, conn.connID)
+		}
+	}
+	m.roomMutex.RUnlock()
+	sort.Strings(members)
+	return members
+}
+
+// RoomCount 获取房间内的连接数，同一connID的多个连接分别计数
+func (m *Manager) RoomCount(room string) int {
+	m.roomMutex.RLock()
+	defer m.roomMutex.RUnlock()
+	return len(m.rooms[room])
+}
+
+// InRoom 判断连接是否在房间中，connID的任一连接在房间中即返回true
+func (m *Manager) InRoom(connID string, room string) bool {
+	conns := m.connsOf(connID)
+	m.roomMutex.RLock()
+	defer m.roomMutex.RUnlock()
+	for _, conn := range conns {
+		if _, ok := conn.rooms[room]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Rooms 获取所有房间及其连接数，按房间名排序
+func (m *Manager) Rooms() []RoomInfo {
+	m.roomMutex.RLock()
+	infos := make([]RoomInfo, 0, len(m.rooms))
+	for room, members := range m.rooms {
+		infos = append(infos, RoomInfo{Room: room, Count: len(members)})
+	}
+	m.roomMutex.RUnlock()
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Room < infos[j].Room
+	})
+	return infos
+}
+
+// ConnRooms 获取连接所在的房间（connID的所有连接合并），按房间名排序
+func (m *Manager) ConnRooms(connID string) []string {
+	conns := m.connsOf(connID)
+	m.roomMutex.RLock()
+	seen := make(map[string]struct{})
+	rooms := make([]string, 0)
+	for _, conn := range conns {
+		for room := range conn.rooms {
+			if _, ok := seen[room]; !ok {
+				seen[room] = struct{}{}
+				rooms = append(rooms, room)
+			}
+		}
+	}
+	m.roomMutex.RUnlock()
+	sort.Strings(rooms)
+	return rooms
+}
+
+// containsString 判断切片是否包含字符串
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,350 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/os/gtime"
+	"github.com/gogf/gf/v2/util/guid"
+)
+
+// MsgTypeError 错误消息的类型
+const MsgTypeError = "error"
+
+// 错误消息的错误码
+const (
+	ErrCodeBadMessage  = 400 // 消息格式错误
+	ErrCodeUnknownType = 404 // 未注册的消息类型
+	ErrCodeBusy        = 429 // 等待处理的消息过多
+	ErrCodeInternal    = 500 // 处理函数返回错误或panic
+)
+
+// DefaultHandleQueueSize 默认每个连接等待处理的路由消息数
+const DefaultHandleQueueSize = 64
+
+// ErrRequestTimeout 等待客户端响应超时
+var ErrRequestTimeout = errors.New("等待客户端响应超时")
+
+// Error 消息处理错误，处理函数返回该类型时错误码会返回给客户端
+type Error struct {
+	Code int    `json:"code" dc:"错误码"`
+	Msg  string `json:"msg" dc:"错误信息"`
+	Type string `json:"type" dc:"出错的消息类型"`
+}
+
+// Error 实现error接口
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+// NewError 创建消息处理错误
+func NewError(code int, msg string) *Error {
+	return &Error{Code: code, Msg: msg}
+}
+
+// Context 消息处理上下文
+type Context struct {
+	context.Context                 // 连接的上下文，连接关闭时取消
+	Manager         *Manager        // 所属管理器
+	Conn            *Connection     // 收到消息的连接
+	Id              string          // 请求ID，客户端需要响应时携带
+	Type            string          // 消息类型
+	Data            json.RawMessage // 消息的data，可通过Bind解析
+}
+
+// ConnID 连接ID
+func (c *Context) ConnID() string {
+	return c.Conn.connID
+}
+
+// Bind 将消息的data解析到pointer
+func (c *Context) Bind(pointer any) error {
+	if len(c.Data) == 0 || string(c.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(c.Data, pointer); err != nil {
+		return &Error{Code: ErrCodeBadMessage, Msg: fmt.Sprintf("消息内容格式错误：%v", err)}
+	}
+	return nil
+}
+
+// Reply 向收到消息的连接发送消息，携带本次请求的ID
+func (c *Context) Reply(msgType string, data any) error {
+	return c.Conn.sendMsg(c.Id, msgType, data)
+}
+
+// HandlerFunc 消息处理函数，返回值不为nil或消息携带id时将结果发送给客户端
+type HandlerFunc func(c *Context) (any, error)
+
+// MiddlewareFunc 消息处理中间件
+type MiddlewareFunc func(next HandlerFunc) HandlerFunc
+
+// Router 消息路由，按Msg.Type分发消息
+/*
+ * 注册了处理函数后，非心跳消息按类型分发，未注册的类型返回错误消息，不再触发OnMessage
+ * 处理函数在每个连接独立的处理协程中按顺序执行，不阻塞读消息和pong，
+ * 处理函数中可以对同一连接调用Manager.Request，等待响应期间该连接的后续消息排队，
+ * 排队的消息超过Config.HandleQueueSize时返回ErrCodeBusy错误
+ */
+type Router struct {
+	handlers   map[string]HandlerFunc
+	middleware []MiddlewareFunc
+	mutex      sync.RWMutex
+}
+
+// newRouter 创建路由
+func newRouter() *Router {
+	return &Router{handlers: make(map[string]HandlerFunc)}
+}
+
+// Use 添加全局中间件，只对之后注册的处理函数生效
+func (r *Router) Use(middleware ...MiddlewareFunc) *Router {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.middleware = append(r.middleware, middleware...)
+	return r
+}
+
+// Handle 注册消息处理函数
+/*
+ * @param msgType string 消息类型
+ * @param handler HandlerFunc 处理函数
+ * @param middleware ...MiddlewareFunc 该处理函数的中间件，在全局中间件之后执行
+ */
+func (r *Router) Handle(msgType string, handler HandlerFunc, middleware ...MiddlewareFunc) *Router {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	all := append(append([]MiddlewareFunc{}, r.middleware...), middleware...)
+	for i := len(all) - 1; i >= 0; i-- {
+		handler = all[i](handler)
+	}
+	r.handlers[msgType] = handler
+	return r
+}
+
+// On 注册消息处理函数，data自动解析为T
+/*
+ * 例：
+ *	ws.On(m.Router(), "chat", func(c *ws.Context, req ChatReq) (any, error) {
+ *		return nil, m.BroadcastToRoom(req.Room, ...)
+ *	})
+ */
+func On[T any](r *Router, msgType string, handler func(c *Context, payload T) (any, error), middleware ...MiddlewareFunc) *Router {
+	return r.Handle(msgType, func(c *Context) (any, error) {
+		var payload T
+		if err := c.Bind(&payload); err != nil {
+			return nil, err
+		}
+		return handler(c, payload)
+	}, middleware...)
+}
+
+// handler 获取处理函数
+func (r *Router) handler(msgType string) (HandlerFunc, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	handler, ok := r.handlers[msgType]
+	return handler, ok
+}
+
+// enabled 是否注册了处理函数
+func (r *Router) enabled() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return len(r.handlers) > 0
+}
+
+// Router 获取消息路由
+func (m *Manager) Router() *Router {
+	return m.router
+}
+
+// inbound 收到的消息
+type inbound struct {
+	Id   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// pendingRequest 等待客户端响应的请求
+type pendingRequest struct {
+	sessionID string
+	reply     chan *inbound
+}
+
+// dispatch 分发非心跳消息
+func (m *Manager) dispatch(c *Connection, msgType int, data []byte) {
+	var msg inbound
+	parsed := json.Unmarshal(data, &msg) == nil
+	// 服务端请求的响应
+	if parsed && msg.Id != "" && m.resolveRequest(c, &msg) {
+		return
+	}
+	if !m.router.enabled() {
+		m.OnMessage(c.connID, msgType, data)
+		return
+	}
+	if !parsed || msg.Type == "" {
+		_ = c.sendError(msg.Id, &Error{Code: ErrCodeBadMessage, Msg: "消息格式错误"})
+		return
+	}
+	select {
+	case c.handleQueue <- &msg:
+	default:
+		_ = c.sendError(msg.Id, &Error{Code: ErrCodeBusy, Msg: "消息处理繁忙，请稍后重试", Type: msg.Type})
+	}
+}
+
+// HandlePump 按顺序处理路由消息（持续运行）
+func (c *Connection) HandlePump() {
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case msg := <-c.handleQueue:
+			c.manager.handle(c, msg)
+		}
+	}
+}
+
+// handle 调用路由处理函数并发送结果
+func (m *Manager) handle(c *Connection, msg *inbound) {
+	// 连接已关闭时不再处理排队的消息
+	if c.ctx.Err() != nil {
+		return
+	}
+	handler, ok := m.router.handler(msg.Type)
+	if !ok {
+		_ = c.sendError(msg.Id, &Error{Code: ErrCodeUnknownType, Msg: fmt.Sprintf("未知的消息类型：%s", msg.Type), Type: msg.Type})
+		return
+	}
+	ctx := &Context{Context: c.ctx, Manager: m, Conn: c, Id: msg.Id, Type: msg.Type, Data: msg.Data}
+	result, err := callHandler(handler, ctx)
+	if err != nil {
+		var msgErr *Error
+		if !errors.As(err, &msgErr) {
+			msgErr = &Error{Code: ErrCodeInternal, Msg: err.Error()}
+		}
+		if msgErr.Type == "" {
+			msgErr = &Error{Code: msgErr.Code, Msg: msgErr.Msg, Type: msg.Type}
+		}
+		_ = c.sendError(msg.Id, msgErr)
+		return
+	}
+	if result != nil || msg.Id != "" {
+		_ = c.sendMsg(msg.Id, msg.Type, result)
+	}
+}
+
+// callHandler 调用处理函数，panic转换为错误
+func callHandler(handler HandlerFunc, ctx *Context) (result any, err error) {
+	defer func() {
+		if exception := recover(); exception != nil {
+			log.Printf("连接[%s]消息[%s]处理panic：%v", ctx.Conn.connID, ctx.Type, exception)
+			err = &Error{Code: ErrCodeInternal, Msg: "消息处理异常"}
+		}
+	}()
+	return handler(ctx)
+}
+
+// resolveRequest 将响应交给等待中的请求，不是响应时返回false
+func (m *Manager) resolveRequest(c *Connection, msg *inbound) bool {
+	m.pendingMutex.Lock()
+	pending, ok := m.pending[msg.Id]
+	if ok && pending.sessionID == c.sessionID {
+		delete(m.pending, msg.Id)
+	}
+	m.pendingMutex.Unlock()
+	if !ok || pending.sessionID != c.sessionID {
+		return false
+	}
+	pending.reply <- msg
+	return true
+}
+
+// Request 向连接发送请求并等待客户端响应，客户端响应时需携带相同的id
+/*
+ * 客户端响应type为error时返回*Error
+ * @param connID string 连接ID，有多个连接时发送到最新建立的连接，发送到指定连接请使用Connection.Request
+ * @param msgType string 消息类型
+ * @param payload any 消息内容
+ * @param timeout time.Duration 等待超时时间，小于等于0时不超时，一直等待到客户端响应或连接关闭
+ * @return json.RawMessage 客户端响应的data
+ */
+func (m *Manager) Request(connID string, msgType string, payload any, timeout time.Duration) (json.RawMessage, error) {
+	conn := m.GetConn(connID)
+	if conn == nil {
+		return nil, fmt.Errorf("连接[%s]不存在", connID)
+	}
+	return conn.Request(msgType, payload, timeout)
+}
+
+// Request 向当前连接发送请求并等待客户端响应，参数同Manager.Request
+func (c *Connection) Request(msgType string, payload any, timeout time.Duration) (json.RawMessage, error) {
+	m := c.manager
+	id := guid.S()
+	pending := &pendingRequest{sessionID: c.sessionID, reply: make(chan *inbound, 1)}
+	m.pendingMutex.Lock()
+	m.pending[id] = pending
+	m.pendingMutex.Unlock()
+	defer func() {
+		m.pendingMutex.Lock()
+		delete(m.pending, id)
+		m.pendingMutex.Unlock()
+	}()
+
+	if err := c.sendMsg(id, msgType, payload); err != nil {
+		return nil, err
+	}
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+	select {
+	case reply := <-pending.reply:
+		if reply.Type == MsgTypeError {
+			msgErr := &Error{Code: ErrCodeInternal, Type: msgType}
+			_ = json.Unmarshal(reply.Data, msgErr)
+			return nil, msgErr
+		}
+		return reply.Data, nil
+	case <-c.ctx.Done():
+		return nil, ErrConnClosed
+	case <-timeoutC:
+		return nil, ErrRequestTimeout
+	}
+}
+
+// RequestAs 发送请求并将客户端响应的data解析为T
+func RequestAs[T any](m *Manager, connID string, msgType string, payload any, timeout time.Duration) (T, error) {
+	var result T
+	data, err := m.Request(connID, msgType, payload, timeout)
+	if err != nil {
+		return result, err
+	}
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &result)
+	}
+	return result, err
+}
+
+// sendMsg 发送Msg格式的消息
+func (c *Connection) sendMsg(id string, msgType string, data any) error {
+	js, err := json.Marshal(&Msg[any]{Type: msgType, Data: data, Timestamp: gtime.Timestamp(), Id: id})
+	if err != nil {
+		return fmt.Errorf("消息编码失败：%w", err)
+	}
+	return c.Send(js)
+}
+
+// sendError 发送错误消息
+func (c *Connection) sendError(id string, err *Error) error {
+	return c.sendMsg(id, MsgTypeError, err)
+}
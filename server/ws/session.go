@@ -0,0 +1,149 @@
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// DuplicatePolicy 同一connID（用户ID）已有连接时的处理策略
+type DuplicatePolicy string
+
+const (
+	DuplicateReject  DuplicatePolicy = "reject"  // 拒绝新连接
+	DuplicateKickOld DuplicatePolicy = "kickOld" // 关闭旧连接，保留新连接
+	DuplicateAllow   DuplicatePolicy = "allow"   // 允许多个连接，如多个浏览器标签页、手机与电脑同时在线
+)
+
+// ErrKicked 旧连接被同一用户的新连接踢下线
+var ErrKicked = errors.New("账号已在其他地方连接")
+
+// SessionID 获取连接的唯一会话ID，同一connID的多个连接会话ID不同
+func (c *Connection) SessionID() string {
+	return c.sessionID
+}
+
+// checkDuplicate 按重复连接策略检查是否允许升级
+func (m *Manager) checkDuplicate(connID string) error {
+	if m.config.DuplicatePolicy != DuplicateReject {
+		return nil
+	}
+	m.mutex.RLock()
+	exists := len(m.users[connID]) > 0
+	m.mutex.RUnlock()
+	if exists {
+		return fmt.Errorf("连接ID[%s]已存在", connID)
+	}
+	return nil
+}
+
+// addConn 添加连接，返回需要踢下线的旧连接及是否为该connID的第一个连接
+func (m *Manager) addConn(conn *Connection) ([]*Connection, bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	sessions := m.users[conn.connID]
+	if len(sessions) > 0 && m.config.DuplicatePolicy == DuplicateReject {
+		// 升级期间有并发的同ID连接完成了升级
+		return nil, false, fmt.Errorf("连接ID[%s]已存在", conn.connID)
+	}
+	first := len(sessions) == 0
+	var kicked []*Connection
+	if m.config.DuplicatePolicy == DuplicateKickOld {
+		for _, old := range sessions {
+			kicked = append(kicked, old)
+		}
+	}
+	if sessions == nil {
+		sessions = make(map[string]*Connection)
+		m.users[conn.connID] = sessions
+	}
+	sessions[conn.sessionID] = conn
+	m.connections[conn.sessionID] = conn
+	return kicked, first, nil
+}
+
+// removeConn 移除连接，返回是否为该connID的最后一个连接
+func (m *Manager) removeConn(conn *Connection) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.connections, conn.sessionID)
+	sessions := m.users[conn.connID]
+	delete(sessions, conn.sessionID)
+	if len(sessions) == 0 {
+		delete(m.users, conn.connID)
+		return true
+	}
+	return false
+}
+
+// connsOf 获取connID的所有连接，不排序
+func (m *Manager) connsOf(connID string) []*Connection {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	conns := make([]*Connection, 0, len(m.users[connID]))
+	for _, conn := range m.users[connID] {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// GetConns 获取connID的所有连接，按连接创建时间排序
+func (m *Manager) GetConns(connID string) []*Connection {
+	m.mutex.RLock()
+	conns := make([]*Connection, 0, len(m.users[connID]))
+	for _, conn := range m.users[connID] {
+		conns = append(conns, conn)
+	}
+	m.mutex.RUnlock()
+	sort.Slice(conns, func(i, j int) bool {
+		return conns[i].createTime.Before(conns[j].createTime)
+	})
+	return conns
+}
+
+// GetSession 根据会话ID获取连接
+func (m *Manager) GetSession(sessionID string) *Connection {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.connections[sessionID]
+}
+
+// GetAllSessions 获取所有在线连接（sessionID -> Connection）
+func (m *Manager) GetAllSessions() map[string]*Connection {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	// 返回副本，防止外部修改
+	connCopy := make(map[string]*Connection, len(m.connections))
+	for k, v := range m.connections {
+		connCopy[k] = v
+	}
+	return connCopy
+}
+
+// IsOnline 判断connID是否有在线连接
+func (m *Manager) IsOnline(connID string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.users[connID]) > 0
+}
+
+// GetUserCount 获取在线的connID数量，GetOnlineCount为连接数
+func (m *Manager) GetUserCount() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.users)
+}
+
+// CloseConn 关闭connID的所有连接
+func (m *Manager) CloseConn(connID string, err error) {
+	for _, conn := range m.connsOf(connID) {
+		conn.Close(err)
+	}
+}
+
+// CloseSession 关闭会话ID对应的连接，同一connID的其他连接不受影响
+func (m *Manager) CloseSession(sessionID string, err error) {
+	if conn := m.GetSession(sessionID); conn != nil {
+		conn.Close(err)
+	}
+}
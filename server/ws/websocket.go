@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gogf/gf/v2/encoding/gjson"
@@ -15,6 +17,7 @@ import (
 	"github.com/gogf/gf/v2/os/gtimer"
 	"github.com/gogf/gf/v2/text/gstr"
 	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/gogf/gf/v2/util/guid"
 	"github.com/gorilla/websocket"
 )
 
@@ -33,7 +36,7 @@ const (
 	// 消息类型
 	MessageTypeText   = websocket.TextMessage
 	MessageTypeBinary = websocket.BinaryMessage
-	// 心跳最大重试次数
+	// 心跳最大重试次数：ServerPing模式下连续未收到pong的次数
 	HeartbeatMaxRetry = 3
 )
 
@@ -49,12 +52,26 @@ type Config struct {
 	// 心跳配置
 	HeartbeatInterval time.Duration // 心跳发送间隔
 	HeartbeatTimeout  time.Duration // 心跳超时时间
+	// 服务端按HeartbeatInterval发送ping控制帧，连续HeartbeatMaxRetry次未收到pong时关闭连接
+	// 浏览器会自动响应pong，客户端的JSON心跳仍然有效
+	// 开启后ReadTimeout、HeartbeatTimeout至少为HeartbeatInterval*(HeartbeatMaxRetry+2)，保证先由重试次数判断超时
+	ServerPing        bool
+	HeartbeatMaxRetry int // 允许连续未收到pong的次数，默认3
 	// 读写超时
 	ReadTimeout    time.Duration
 	WriteTimeout   time.Duration
 	MsgType        int    // 发送消息的默认类型
 	HeartbeatValue string // 心跳消息的标识字段值（如"heartbeat"、"pong"）
 	HeartbeatKey   string // 心跳消息的标识字段名（如"type"）
+	// 同一connID已有连接时的处理策略，默认拒绝新连接
+	DuplicatePolicy DuplicatePolicy
+	// 发送队列配置
+	SendQueueSize  int            // 每个连接的发送队列长度，默认256
+	WriteBatchSize int            // 每次批量写入的最大消息数，默认16
+	OverflowPolicy OverflowPolicy // 队列已满时的处理策略，默认断开连接
+	EnqueueTimeout time.Duration  // OverflowBlock策略的最长等待时间，默认同WriteTimeout
+	// 每个连接等待处理的路由消息数，默认64，已满时返回繁忙错误
+	HandleQueueSize int
 }
 
 // 默认配置
@@ -66,40 +83,75 @@ func DefaultConfig() *Config {
 		AllowedOrigins:    []string{},
 		HeartbeatInterval: DefaultHeartbeatInterval,
 		HeartbeatTimeout:  DefaultHeartbeatTimeout,
+		HeartbeatMaxRetry: HeartbeatMaxRetry,
 		ReadTimeout:       DefaultReadTimeout,
 		WriteTimeout:      DefaultWriteTimeout,
 		MsgType:           MessageTypeText,
 		HeartbeatValue:    "heartbeat",
 		HeartbeatKey:      "type", // 心跳消息的标识字段名，默认"type"
+		SendQueueSize:     DefaultSendQueueSize,
+		WriteBatchSize:    DefaultWriteBatchSize,
+		OverflowPolicy:    OverflowDisconnect,
+		EnqueueTimeout:    DefaultWriteTimeout,
+		DuplicatePolicy:   DuplicateReject,
+		HandleQueueSize:   DefaultHandleQueueSize,
 	}
 }
 
 // Connection WebSocket连接结构体
 type Connection struct {
 	conn           *websocket.Conn // 底层连接
-	connID         string          // 唯一连接ID
+	connID         string          // 连接ID（如用户ID），同一connID可有多个连接
+	sessionID      string          // 唯一会话ID
 	manager        *Manager        // 所属管理器
 	createTime     time.Time       // 连接创建时间
 	heartbeatChan  time.Time       // 心跳通道（用于检测客户端响应）
-	heartbeatTime  *gtimer.Entry
-	ctx            context.Context    // 上下文
-	cancel         context.CancelFunc // 上下文取消函数
-	writeMutex     sync.Mutex         // 写消息互斥锁（防止并发写）
-	heartbeatRetry int                // 心跳发送重试次数
+	heartbeatTime  atomic.Pointer[gtimer.Entry]
+	ctx            context.Context     // 上下文
+	cancel         context.CancelFunc  // 上下文取消函数
+	writeMutex     sync.Mutex          // 写消息互斥锁（防止并发写）
+	heartbeatRetry atomic.Int32        // 连续未收到pong的次数
+	lastPing       atomic.Int64        // 最近一次发送ping的时间（纳秒）
+	lastPong       atomic.Int64        // 最近一次收到pong的时间（纳秒）
+	rtt            atomic.Int64        // 最近一次ping/pong往返时间（纳秒）
+	queue          chan *outbound      // 发送队列
+	handleQueue    chan *inbound       // 等待路由处理的消息
+	queueMutex     sync.RWMutex        // 保护queueClosed
+	queueClosed    bool                // 发送队列是否已关闭
+	closeOnce      sync.Once           // 保证只关闭一次
+	rooms          map[string]struct{} // 所在房间，由manager.roomMutex保护
+	expireTimer    *time.Timer         // 凭证过期计时
+	expireMutex    sync.Mutex          // 保护expireTimer
+	attrs          map[string]any      // 连接属性，由manager.attrMutex保护
 }
 
 // Manager WebSocket连接管理器
 type Manager struct {
-	config      *Config                // 配置
-	upgrader    *websocket.Upgrader    // HTTP升级器
-	connections map[string]*Connection // 所有在线连接（connID -> Connection）
-	mutex       sync.RWMutex           // 读写锁（保护connections）
+	config       *Config                                      // 配置
+	upgrader     *websocket.Upgrader                          // HTTP升级器
+	connections  map[string]*Connection                       // 所有在线连接（sessionID -> Connection）
+	users        map[string]map[string]*Connection            // connID的所有连接（connID -> sessionID -> Connection）
+	mutex        sync.RWMutex                                 // 读写锁（保护connections、users）
+	rooms        map[string]map[string]*Connection            // 房间（room -> sessionID -> Connection）
+	roomMutex    sync.RWMutex                                 // 读写锁（保护rooms及Connection.rooms）
+	router       *Router                                      // 消息路由
+	pending      map[string]*pendingRequest                   // 等待客户端响应的请求（id -> 请求）
+	pendingMutex sync.Mutex                                   // 互斥锁（保护pending）
+	backplane    atomic.Pointer[backplane]                    // 跨节点状态，未开启时为nil
+	attrIndex    map[string]map[string]map[string]*Connection // 属性索引（属性名 -> 属性值 -> sessionID -> Connection）
+	attrMutex    sync.RWMutex                                 // 读写锁（保护attrIndex及Connection.attrs）
+	// 升级前的认证函数（可选），设置后连接ID取自认证结果
+	Authenticator Authenticator
 	// 业务回调：收到消息时触发（用户自定义处理逻辑）
 	OnMessage func(connID string, msgType int, data any)
 	// 业务回调：连接建立时触发
 	OnConnect func(connID string)
-	// 业务回调：连接关闭时触发
-	OnDisconnect func(connID string, err error)
+	// 业务回调：连接关闭时触发，last表示该connID的最后一个连接已关闭
+	OnDisconnect func(connID string, err error, last bool)
+	// 业务回调：连接加入房间时触发（可选）
+	OnJoinRoom func(connID string, room string)
+	// 业务回调：连接退出房间时触发，连接关闭导致的退出也会触发（可选）
+	OnLeaveRoom func(connID string, room string)
 }
 
 // Merge 合并配置，用传入的配置覆盖非零值部分
@@ -119,6 +171,12 @@ func (c *Config) Merge(other *Config) *Config {
 	if other.HeartbeatInterval > 0 {
 		result.HeartbeatInterval = other.HeartbeatInterval
 	}
+	if other.ServerPing {
+		result.ServerPing = other.ServerPing
+	}
+	if other.HeartbeatMaxRetry > 0 {
+		result.HeartbeatMaxRetry = other.HeartbeatMaxRetry
+	}
 	if other.HeartbeatTimeout > 0 {
 		result.HeartbeatTimeout = other.HeartbeatTimeout
 	}
@@ -143,6 +201,32 @@ func (c *Config) Merge(other *Config) *Config {
 	if other.MsgType != 0 {
 		result.MsgType = other.MsgType
 	}
+	if other.DuplicatePolicy != "" {
+		result.DuplicatePolicy = other.DuplicatePolicy
+	}
+	if other.SendQueueSize > 0 {
+		result.SendQueueSize = other.SendQueueSize
+	}
+	if other.WriteBatchSize > 0 {
+		result.WriteBatchSize = other.WriteBatchSize
+	}
+	if other.OverflowPolicy != "" {
+		result.OverflowPolicy = other.OverflowPolicy
+	}
+	if other.EnqueueTimeout > 0 {
+		result.EnqueueTimeout = other.EnqueueTimeout
+	} else if other.WriteTimeout > 0 {
+		result.EnqueueTimeout = other.WriteTimeout
+	}
+	if other.HandleQueueSize > 0 {
+		result.HandleQueueSize = other.HandleQueueSize
+	}
+	// ServerPing在第HeartbeatMaxRetry+1次ping时关闭连接，读超时和心跳超时需留出一个间隔
+	if result.ServerPing {
+		minTimeout := result.HeartbeatInterval * time.Duration(result.HeartbeatMaxRetry+2)
+		result.ReadTimeout = max(result.ReadTimeout, minTimeout)
+		result.HeartbeatTimeout = max(result.HeartbeatTimeout, minTimeout)
+	}
 
 	return &result
 }
@@ -153,11 +237,11 @@ func NewManager(config *Config) *Manager {
 	finalConfig := defaultConfig.Merge(config)
 	// 初始化升级器
 	upgrader := &websocket.Upgrader{
-		ReadBufferSize:  config.ReadBufferSize,
-		WriteBufferSize: config.WriteBufferSize,
+		ReadBufferSize:  finalConfig.ReadBufferSize,
+		WriteBufferSize: finalConfig.WriteBufferSize,
 		CheckOrigin: func(r *http.Request) bool {
 			// 跨域检查
-			if config.AllowAllOrigins {
+			if finalConfig.AllowAllOrigins {
 				return true
 			}
 			origin := r.Header.Get("Origin")
@@ -174,7 +258,12 @@ func NewManager(config *Config) *Manager {
 		config:      finalConfig,
 		upgrader:    upgrader,
 		connections: make(map[string]*Connection),
+		users:       make(map[string]map[string]*Connection),
 		mutex:       sync.RWMutex{},
+		rooms:       make(map[string]map[string]*Connection),
+		attrIndex:   make(map[string]map[string]map[string]*Connection),
+		router:      newRouter(),
+		pending:     make(map[string]*pendingRequest),
 		// 默认回调（用户可覆盖）
 		OnMessage: func(connID string, msgType int, data any) {
 			log.Printf("[默认回调] 收到连接[%s]消息：%s", connID, gconv.String(data))
@@ -182,29 +271,38 @@ func NewManager(config *Config) *Manager {
 		OnConnect: func(connID string) {
 			log.Printf("[默认回调] 连接[%s]已建立", connID)
 		},
-		OnDisconnect: func(connID string, err error) {
+		OnDisconnect: func(connID string, err error, last bool) {
 			log.Printf("[默认回调] 连接[%s]已关闭：%v", connID, err)
 		},
 	}
 }
 
 // Upgrade HTTP升级为WebSocket连接
-// connID：自定义连接唯一ID（如用户ID、设备ID）
+// connID：自定义连接ID（如用户ID、设备ID），已存在时按Config.DuplicatePolicy处理
+// 设置了Authenticator时先认证，connID为空则取认证结果，不为空时须与认证结果一致
 func (m *Manager) Upgrade(w http.ResponseWriter, r *http.Request, connID string) (*Connection, error) {
-	if connID == "" {
+	if connID == "" && m.Authenticator == nil {
 		return nil, errors.New("连接ID不能为空")
 	}
 
+	// 认证
+	identity, err := m.authenticate(w, r, connID)
+	if err != nil {
+		return nil, err
+	}
+	connID = identity.ConnID
+
 	// 检查连接ID是否已存在
-	m.mutex.RLock()
-	_, exists := m.connections[connID]
-	m.mutex.RUnlock()
-	if exists {
-		return nil, fmt.Errorf("连接ID[%s]已存在", connID)
+	if err = m.checkDuplicate(connID); err != nil {
+		return nil, err
 	}
 
 	// 升级HTTP连接
-	conn, err := m.upgrader.Upgrade(w, r, nil)
+	var header http.Header
+	if identity.Protocol != "" {
+		header = http.Header{"Sec-Websocket-Protocol": {identity.Protocol}}
+	}
+	conn, err := m.upgrader.Upgrade(w, r, header)
 	if err != nil {
 		return nil, fmt.Errorf("升级WebSocket失败：%w", err)
 	}
@@ -214,36 +312,61 @@ func (m *Manager) Upgrade(w http.ResponseWriter, r *http.Request, connID string)
 
 	// 创建连接实例
 	wsConn := &Connection{
-		conn:           conn,
-		connID:         connID,
-		manager:        m,
-		createTime:     time.Now(),
-		heartbeatChan:  time.Now(), // 缓冲1，防止阻塞
-		ctx:            ctx,
-		cancel:         cancel,
-		writeMutex:     sync.Mutex{},
-		heartbeatRetry: 0,
-	}
-	wsConn.heartbeatTime = gtimer.AddSingleton(gctx.New(), m.config.HeartbeatTimeout, func(ctx context.Context) {
+		conn:          conn,
+		connID:        connID,
+		sessionID:     guid.S(),
+		manager:       m,
+		createTime:    time.Now(),
+		heartbeatChan: time.Now(), // 缓冲1，防止阻塞
+		ctx:           ctx,
+		cancel:        cancel,
+		writeMutex:    sync.Mutex{},
+		queue:         make(chan *outbound, m.config.SendQueueSize),
+		handleQueue:   make(chan *inbound, m.config.HandleQueueSize),
+		rooms:         make(map[string]struct{}),
+		attrs:         make(map[string]any),
+	}
+	for key, value := range identity.Attrs {
+		if value != nil {
+			wsConn.attrs[key] = value
+		}
+	}
+	wsConn.heartbeatTime.Store(gtimer.AddSingleton(gctx.New(), m.config.HeartbeatTimeout, func(ctx context.Context) {
 		log.Printf("[心跳检测] 连接[%s]已关闭：心跳超时", wsConn.connID)
-		wsConn.heartbeatTime.Close()
-		wsConn.heartbeatTime.Stop()
-		wsConn.heartbeatTime = nil
-		wsConn.ctx.Done()
+		wsConn.stopHeartbeat()
 		wsConn.Close(fmt.Errorf("心跳超时"))
-	})
+	}))
 	// 添加到管理器
-	m.mutex.Lock()
-	m.connections[connID] = wsConn
-	m.mutex.Unlock()
+	kicked, first, err := m.addConn(wsConn)
+	if err != nil {
+		wsConn.stopHeartbeat()
+		cancel()
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()), time.Now().Add(time.Second))
+		_ = conn.Close()
+		return nil, err
+	}
+	for _, old := range kicked {
+		old.Close(ErrKicked)
+	}
+	m.indexConn(wsConn)
+	if first {
+		m.presenceChanged(connID, true)
+	}
+	wsConn.SetExpire(identity.ExpireAt)
 
 	// 触发连接建立回调
 	m.OnConnect(connID)
 
+	if m.config.ServerPing {
+		conn.SetPongHandler(wsConn.handlePong)
+	}
+
 	// 启动读消息协程
 	go wsConn.ReadPump()
 	// 启动写消息协程（处理异步发送）
 	go wsConn.WritePump()
+	// 启动路由处理协程，处理函数不阻塞读消息协程
+	go wsConn.HandlePump()
 	// 启动心跳检测协程
 	go wsConn.Heartbeat()
 
@@ -302,7 +425,7 @@ func (c *Connection) ReadPump() {
 			if isHeartbeat {
 				log.Printf("[心跳] 收到连接[%s]心跳消息：%s", c.connID, string(data))
 				// 心跳消息：重置重试次数 + 发送心跳信号 + 重置读超时
-				js, err := gjson.Encode(&Msg[any]{c.manager.config.HeartbeatValue, nil, gtime.Timestamp()})
+				js, err := gjson.Encode(&Msg[any]{Type: c.manager.config.HeartbeatValue, Timestamp: gtime.Timestamp()})
 				if err != nil {
 					log.Printf("[心跳] 客户端[%s]json编码失败", c.connID)
 					continue
@@ -312,12 +435,12 @@ func (c *Connection) ReadPump() {
 					log.Printf("[心跳] 客户端[%s]发送心跳消息失败", c.connID)
 					continue
 				}
-				c.heartbeatTime.Reset()
+				c.resetHeartbeat()
 				continue // 跳过业务回调
 			}
 
-			// 非心跳消息：触发业务回调
-			c.manager.OnMessage(c.connID, msgType, data)
+			// 非心跳消息：交给消息路由，未注册路由时触发业务回调
+			c.manager.dispatch(c, msgType, data)
 		}
 	}
 }
@@ -326,76 +449,104 @@ type Msg[T any] struct {
 	Type      string `json:"type"`
 	Data      T      `json:"data"`
 	Timestamp int64  `json:"timestamp"`
+	Id        string `json:"id,omitempty"` // 请求ID，用于请求与响应关联
 }
 
-// WritePump 处理异步写消息（持续运行）
-// 扩展为监听写队列，防止消息丢失
+// WritePump 从发送队列取出消息批量写入客户端（持续运行）
 func (c *Connection) WritePump() {
 	defer func() {
 		if err := recover(); err != nil {
 			log.Printf("连接[%s]写消息协程panic：%v", c.connID, err)
+			c.Close(fmt.Errorf("写消息协程退出"))
 		}
 	}()
 
-	// 暂时保持简化，实际可扩展为带缓冲的写队列
-	<-c.ctx.Done()
+	batch := make([]*outbound, 0, c.manager.config.WriteBatchSize)
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case item := <-c.queue:
+			// 取出队列中已有的消息，一次写入
+			batch = append(batch[:0], item)
+		collect:
+			for len(batch) < cap(batch) {
+				select {
+				case item = <-c.queue:
+					batch = append(batch, item)
+				default:
+					break collect
+				}
+			}
+			if err := c.writeBatch(batch); err != nil {
+				c.Close(fmt.Errorf("发送消息失败：%w", err))
+				return
+			}
+		}
+	}
 }
 
-// Heartbeat 心跳检测（持续运行）
+// Heartbeat 心跳检测（持续运行），开启ServerPing时定时发送ping
 func (c *Connection) Heartbeat() {
 	defer func() {
 		if err := recover(); err != nil {
 			log.Printf("连接[%s]心跳协程panic：%v", c.connID, err)
 		}
 	}()
-	c.heartbeatTime.Start()
+	if entry := c.heartbeatTime.Load(); entry != nil {
+		entry.Start()
+	}
+	if c.manager.config.ServerPing {
+		c.pingLoop()
+	}
 }
 
-// Send 发送消息到客户端（线程安全）
+// Send 将消息放入发送队列（线程安全），不等待写入客户端
+// 队列已满时按Config.OverflowPolicy处理，需要确认写入结果时使用SendAsync
 func (c *Connection) Send(data []byte) error {
 	select {
 	case <-c.ctx.Done():
-		return errors.New("连接已关闭，无法发送消息")
+		return ErrConnClosed
 	default:
-		// 加锁防止并发写
-		c.writeMutex.Lock()
-		defer c.writeMutex.Unlock()
-
-		// 设置写超时
-		c.conn.SetWriteDeadline(time.Now().Add(c.manager.config.WriteTimeout))
-
-		// 发送消息（使用连接的默认类型，支持动态调整）
-		err := c.conn.WriteMessage(c.manager.config.MsgType, data)
-		if err != nil {
-			return fmt.Errorf("发送消息失败：%w", err)
-		}
-		return nil
+		return c.enqueue(&outbound{msgType: c.manager.config.MsgType, data: data})
 	}
 }
 
 // Close 关闭连接（优雅清理）
 func (c *Connection) Close(err error) {
+	c.CloseWithCode(websocket.CloseNormalClosure, err)
+}
+
+// CloseWithCode 使用指定关闭码关闭连接，如CloseTokenExpired
+func (c *Connection) CloseWithCode(code int, err error) {
 	// 防止重复关闭
-	select {
-	case <-c.ctx.Done():
-		return
-	default:
-	}
+	c.closeOnce.Do(func() {
+		c.close(code, err)
+	})
+}
 
+// close 关闭连接并从管理器移除
+func (c *Connection) close(code int, err error) {
 	// 取消上下文（终止所有协程）
 	c.cancel()
+	c.closeQueue()
+	c.stopExpire()
+	c.stopHeartbeat()
 
-	// 关闭底层连接（友好关闭）
-	_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, err.Error()))
+	// 关闭底层连接（友好关闭），WriteControl可与WritePump并发调用
+	_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, err.Error()), time.Now().Add(time.Second))
 	_ = c.conn.Close()
 
 	// 从管理器移除
-	c.manager.mutex.Lock()
-	delete(c.manager.connections, c.connID)
-	c.manager.mutex.Unlock()
+	last := c.manager.removeConn(c)
+	c.manager.leaveAllRooms(c)
+	c.manager.unindexConn(c)
+	if last {
+		c.manager.presenceChanged(c.connID, false)
+	}
 
 	// 触发断开回调
-	c.manager.OnDisconnect(c.connID, err)
+	c.manager.OnDisconnect(c.connID, err, last)
 
 	log.Printf("连接[%s]已关闭，当前在线数：%d，原因：%v", c.connID, c.manager.GetOnlineCount(), err)
 }
@@ -407,65 +558,157 @@ func (m *Manager) GetOnlineCount() int {
 	return len(m.connections)
 }
 
-// Broadcast 广播消息到所有在线连接
+// Broadcast 广播消息到所有在线连接，消息放入各连接的发送队列，慢连接不影响其他连接
+// 开启跨节点发送时同时发送到其他节点
 func (m *Manager) Broadcast(data []byte) error {
-	m.mutex.RLock()
-	defer m.mutex.RUnlock()
-
-	if len(m.connections) == 0 {
+	count, err := m.broadcastLocal(data)
+	if bp := m.backplane.Load(); bp != nil {
+		return errors.Join(err, bp.publish(&Envelope{Kind: envelopeBroadcast, Data: data}))
+	}
+	if count == 0 {
 		return errors.New("无在线连接")
 	}
+	return err
+}
 
-	// 并发发送（非阻塞）
-	var wg sync.WaitGroup
-	var errMsg string
-
+// broadcastLocal 广播消息到当前节点的所有连接，返回连接数
+func (m *Manager) broadcastLocal(data []byte) (int, error) {
+	m.mutex.RLock()
+	conns := make([]*Connection, 0, len(m.connections))
 	for _, conn := range m.connections {
-		wg.Add(1)
-		go func(c *Connection) {
-			defer wg.Done()
-			if err := c.Send(data); err != nil {
-				errMsg += fmt.Sprintf("连接[%s]广播失败：%v；", c.connID, err)
-			}
-		}(conn)
+		conns = append(conns, conn)
 	}
+	m.mutex.RUnlock()
 
-	wg.Wait()
+	var errMsg string
+	for _, c := range conns {
+		if err := c.Send(data); err != nil {
+			errMsg += fmt.Sprintf("连接[%s]广播失败：%v；", c.connID, err)
+		}
+	}
 
 	if errMsg != "" {
-		return errors.New(errMsg)
+		return len(conns), errors.New(errMsg)
 	}
-	return nil
+	return len(conns), nil
 }
 
-// SendToConn 定向发送消息到指定连接
+// SendToConn 定向发送消息到connID的所有连接，发送到单个连接请使用SendToSession
+// 开启跨节点发送时，connID在其他节点在线或当前节点不存在时同时发送到其他节点
 func (m *Manager) SendToConn(connID string, data []byte) error {
-	m.mutex.RLock()
-	conn, exists := m.connections[connID]
-	m.mutex.RUnlock()
-
-	if !exists {
+	found, err := m.sendLocal(connID, data)
+	remote := false
+	if bp := m.backplane.Load(); bp != nil {
+		remote = len(bp.remoteNodes(connID)) > 0
+		if remote || !found {
+			err = errors.Join(err, bp.publish(&Envelope{Kind: envelopeConn, Target: connID, Data: data}))
+		}
+	}
+	if !found && !remote {
 		return fmt.Errorf("连接[%s]不存在", connID)
 	}
+	return err
+}
+
+// SendToSession 定向发送消息到会话ID对应的连接
+// 开启跨节点发送时，会话不在当前节点则发送到其他节点
+func (m *Manager) SendToSession(sessionID string, data []byte) error {
+	if conn := m.GetSession(sessionID); conn != nil {
+		return conn.Send(data)
+	}
+	if bp := m.backplane.Load(); bp != nil {
+		return bp.publish(&Envelope{Kind: envelopeSession, Target: sessionID, Data: data})
+	}
+	return fmt.Errorf("会话[%s]不存在", sessionID)
+}
+
+// sendLocal 发送消息到当前节点connID的所有连接，返回连接是否存在
+func (m *Manager) sendLocal(connID string, data []byte) (bool, error) {
+	conns := m.connsOf(connID)
+	if len(conns) == 0 {
+		return false, nil
+	}
+
+	var errMsg string
+	for _, c := range conns {
+		if err := c.Send(data); err != nil {
+			errMsg += fmt.Sprintf("连接[%s]会话[%s]发送失败：%v；", c.connID, c.sessionID, err)
+		}
+	}
+	if errMsg != "" {
+		return true, errors.New(errMsg)
+	}
+	return true, nil
+}
+
+// ConnInfo 连接信息快照
+type ConnInfo struct {
+	ConnID     string         `json:"connId" dc:"连接ID"`
+	SessionID  string         `json:"sessionId" dc:"会话ID"`
+	RemoteAddr string         `json:"remoteAddr" dc:"客户端地址"`
+	CreateTime time.Time      `json:"createTime" dc:"连接创建时间"`
+	RTT        int64          `json:"rtt" dc:"最近一次ping/pong往返时间（毫秒），未开启ServerPing时为0"`
+	Attrs      map[string]any `json:"attrs" dc:"连接属性"`
+}
+
+// ID 获取连接ID
+func (c *Connection) ID() string {
+	return c.connID
+}
+
+// Info 获取连接信息快照
+func (c *Connection) Info() ConnInfo {
+	return ConnInfo{
+		ConnID:     c.connID,
+		SessionID:  c.sessionID,
+		RemoteAddr: c.conn.RemoteAddr().String(),
+		CreateTime: c.createTime,
+		RTT:        c.RTT().Milliseconds(),
+		Attrs:      c.Attrs(),
+	}
+}
 
-	return conn.Send(data)
+// Snapshot 获取所有在线连接的信息快照，按连接创建时间排序
+func (m *Manager) Snapshot() []ConnInfo {
+	m.mutex.RLock()
+	infos := make([]ConnInfo, 0, len(m.connections))
+	for _, conn := range m.connections {
+		infos = append(infos, conn.Info())
+	}
+	m.mutex.RUnlock()
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].CreateTime.Before(infos[j].CreateTime)
+	})
+	return infos
 }
 
+// GetAllConn 获取所有在线连接（connID -> 该connID最新建立的连接），全部连接请使用GetAllSessions
 func (m *Manager) GetAllConn() map[string]*Connection {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 	// 返回副本，防止外部修改
-	connCopy := make(map[string]*Connection, len(m.connections))
-	for k, v := range m.connections {
-		connCopy[k] = v
+	connCopy := make(map[string]*Connection, len(m.users))
+	for connID, sessions := range m.users {
+		for _, conn := range sessions {
+			if latest, ok := connCopy[connID]; !ok || conn.createTime.After(latest.createTime) {
+				connCopy[connID] = conn
+			}
+		}
 	}
 	return connCopy
 }
 
+// GetConn 获取connID最新建立的连接，按会话ID获取请使用GetSession
 func (m *Manager) GetConn(connID string) *Connection {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
-	return m.connections[connID]
+	var latest *Connection
+	for _, conn := range m.users[connID] {
+		if latest == nil || conn.createTime.After(latest.createTime) {
+			latest = conn
+		}
+	}
+	return latest
 }
 
 // CloseAll 关闭所有连接
@@ -0,0 +1,339 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// disconnectEvent OnDisconnect回调参数
+type disconnectEvent struct {
+	connID string
+	err    error
+	last   bool
+}
+
+// newTestManager 创建管理器并启动测试服务，连接ID取自查询参数id
+func newTestManager(t *testing.T, config *Config) (*Manager, string) {
+	t.Helper()
+	if config == nil {
+		config = &Config{}
+	}
+	config.AllowAllOrigins = true
+	m := NewManager(config)
+	m.OnConnect = func(connID string) {}
+	m.OnDisconnect = func(connID string, err error, last bool) {}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := m.Upgrade(w, r, r.URL.Query().Get("id")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(func() {
+		m.CloseAll()
+		srv.Close()
+	})
+	return m, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// dial 以connID建立客户端连接
+func dial(t *testing.T, url string, connID string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?id="+connID, nil)
+	if err != nil {
+		t.Fatalf("连接[%s]失败: %v", connID, err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+// drain 持续读取客户端消息，返回收到的消息通道，连接关闭时关闭通道
+func drain(conn *websocket.Conn) <-chan string {
+	messages := make(chan string, 1024)
+	go func() {
+		defer close(messages)
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			select {
+			case messages <- string(data):
+			default:
+			}
+		}
+	}()
+	return messages
+}
+
+// expectMessage 在超时前收到指定消息
+func expectMessage(t *testing.T, messages <-chan string, want string) {
+	t.Helper()
+	timer := time.NewTimer(2 * time.Second)
+	defer timer.Stop()
+	for {
+		select {
+		case got, ok := <-messages:
+			if !ok {
+				t.Fatalf("连接已关闭，未收到消息%q", want)
+			}
+			if got == want {
+				return
+			}
+		case <-timer.C:
+			t.Fatalf("未收到消息%q", want)
+		}
+	}
+}
+
+// waitFor 等待条件成立
+func waitFor(t *testing.T, desc string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待超时：%s", desc)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestBroadcastToRoomWhileLeaving 房间广播与连接退出房间、断开并发进行
+func TestBroadcastToRoomWhileLeaving(t *testing.T) {
+	m, url := newTestManager(t, &Config{DuplicatePolicy: DuplicateAllow})
+	m.OnConnect = func(connID string) {
+		_ = m.JoinRoom(connID, "room")
+	}
+	const clients = 20
+	conns := make([]*websocket.Conn, clients)
+	for i := range conns {
+		conns[i] = dial(t, url, fmt.Sprintf("user%d", i))
+		drain(conns[i])
+	}
+	waitFor(t, "全部连接加入房间", func() bool { return m.RoomCount("room") == clients })
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			_ = m.BroadcastToRoom("room", []byte("hello"))
+		}
+	}()
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			switch i % 3 {
+			case 0:
+				m.LeaveRoom(fmt.Sprintf("user%d", i), "room")
+			case 1:
+				_ = conns[i].Close()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// i%3==2的连接仍在房间中
+	remain := 0
+	for i := 0; i < clients; i++ {
+		if i%3 == 2 {
+			remain++
+		}
+	}
+	waitFor(t, "退出和断开的连接移出房间", func() bool { return m.RoomCount("room") == remain })
+	for _, connID := range m.RoomMembers("room") {
+		if !m.IsOnline(connID) {
+			t.Fatalf("已断开的连接[%s]残留在房间中", connID)
+		}
+	}
+}
+
+// TestSlowConsumer 客户端不读取消息，发送队列写满后按策略处理
+func TestSlowConsumer(t *testing.T) {
+	payload := make([]byte, 1<<20)
+	cases := []struct {
+		policy OverflowPolicy
+		err    error
+		online bool
+	}{
+		{OverflowDisconnect, ErrQueueFull, false},
+		{OverflowDropNewest, ErrDropped, true},
+	}
+	for _, tc := range cases {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			m, url := newTestManager(t, &Config{SendQueueSize: 2, WriteBatchSize: 1, OverflowPolicy: tc.policy, WriteTimeout: 10 * time.Second})
+			disconnected := make(chan disconnectEvent, 1)
+			m.OnDisconnect = func(connID string, err error, last bool) {
+				disconnected <- disconnectEvent{connID, err, last}
+			}
+			dial(t, url, "slow")
+			waitFor(t, "连接建立", func() bool { return m.IsOnline("slow") })
+
+			conn := m.GetConn("slow")
+			var err error
+			for i := 0; i < 200 && err == nil; i++ {
+				err = conn.Send(payload)
+			}
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("队列已满时返回%v，期望%v", err, tc.err)
+			}
+			if tc.online {
+				if !m.IsOnline("slow") {
+					t.Fatalf("丢弃消息策略不应断开连接")
+				}
+				return
+			}
+			select {
+			case event := <-disconnected:
+				if event.connID != "slow" || !event.last {
+					t.Fatalf("断开回调参数错误：%+v", event)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("消费过慢的连接未断开")
+			}
+		})
+	}
+}
+
+// TestMultiSessionDisconnect 同一connID的多个连接，最后一个连接断开时last为true
+func TestMultiSessionDisconnect(t *testing.T) {
+	m, url := newTestManager(t, &Config{DuplicatePolicy: DuplicateAllow})
+	events := make(chan disconnectEvent, 2)
+	m.OnDisconnect = func(connID string, err error, last bool) {
+		events <- disconnectEvent{connID, err, last}
+	}
+	first := dial(t, url, "user")
+	waitFor(t, "第一个连接建立", func() bool { return len(m.GetConns("user")) == 1 })
+	second := dial(t, url, "user")
+	messages := drain(second)
+	waitFor(t, "第二个连接建立", func() bool { return len(m.GetConns("user")) == 2 })
+	if sessions := m.GetAllSessions(); len(sessions) != 2 {
+		t.Fatalf("会话数为%d，期望2", len(sessions))
+	}
+	if conns := m.GetAllConn(); len(conns) != 1 || conns["user"] == nil {
+		t.Fatalf("GetAllConn应按connID返回：%v", conns)
+	}
+
+	_ = first.Close()
+	event := <-events
+	if event.last {
+		t.Fatalf("仍有其他连接时last应为false")
+	}
+	if !m.IsOnline("user") {
+		t.Fatalf("仍有其他连接时应在线")
+	}
+	if err := m.SendToConn("user", []byte("still here")); err != nil {
+		t.Fatalf("发送到剩余连接失败: %v", err)
+	}
+	expectMessage(t, messages, "still here")
+
+	_ = second.Close()
+	event = <-events
+	if !event.last || event.connID != "user" {
+		t.Fatalf("最后一个连接断开时last应为true：%+v", event)
+	}
+	if m.IsOnline("user") {
+		t.Fatalf("全部连接断开后不应在线")
+	}
+}
+
+// TestMemoryBackplane 通过内存发布订阅通道发送到其他节点的连接
+func TestMemoryBackplane(t *testing.T) {
+	bus := NewMemoryBackplane()
+	a, urlA := newTestManager(t, nil)
+	b, _ := newTestManager(t, nil)
+	for _, m := range []*Manager{a, b} {
+		if err := m.UseBackplane(&BackplaneOption{Backplane: bus}); err != nil {
+			t.Fatalf("开启跨节点发送失败: %v", err)
+		}
+		t.Cleanup(func() {
+			_ = m.StopBackplane()
+		})
+	}
+	a.OnConnect = func(connID string) {
+		_ = a.JoinRoom(connID, "room")
+	}
+	conn := dial(t, urlA, "remote")
+	messages := drain(conn)
+	waitFor(t, "其他节点同步在线状态", func() bool { return b.IsClusterOnline("remote") })
+	if nodes := b.Presence("remote"); len(nodes) != 1 || nodes[0] != a.Node() {
+		t.Fatalf("connID所在节点错误：%v", nodes)
+	}
+
+	if err := b.SendToConn("remote", []byte("to conn")); err != nil {
+		t.Fatalf("跨节点定向发送失败: %v", err)
+	}
+	expectMessage(t, messages, "to conn")
+	session := a.GetConn("remote").sessionID
+	if err := b.SendToSession(session, []byte("to session")); err != nil {
+		t.Fatalf("跨节点发送到会话失败: %v", err)
+	}
+	expectMessage(t, messages, "to session")
+	if err := b.BroadcastToRoom("room", []byte("to room")); err != nil {
+		t.Fatalf("跨节点房间广播失败: %v", err)
+	}
+	expectMessage(t, messages, "to room")
+
+	_ = conn.Close()
+	waitFor(t, "其他节点同步下线", func() bool { return !b.IsClusterOnline("remote") })
+}
+
+// TestRouterRequestSameConn 处理函数中向同一连接发送请求不阻塞读消息
+func TestRouterRequestSameConn(t *testing.T) {
+	m, url := newTestManager(t, nil)
+	m.Router().Handle("ask", func(c *Context) (any, error) {
+		data, err := c.Conn.Request("question", nil, time.Second)
+		return string(data), err
+	})
+	conn := dial(t, url, "user")
+	if err := conn.WriteJSON(map[string]any{"type": "ask", "id": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var question Msg[any]
+	if err := conn.ReadJSON(&question); err != nil || question.Type != "question" {
+		t.Fatalf("未收到服务端请求：%+v %v", question, err)
+	}
+	if err := conn.WriteJSON(map[string]any{"type": "question", "id": question.Id, "data": "answer"}); err != nil {
+		t.Fatal(err)
+	}
+	var reply Msg[json.RawMessage]
+	if err := conn.ReadJSON(&reply); err != nil || reply.Id != "1" || string(reply.Data) != `"\"answer\""` {
+		t.Fatalf("处理结果错误：%+v %v", reply, err)
+	}
+}
+
+// TestServerPingRetry 客户端不响应ping时按重试次数断开，超时时间不小于重试窗口
+func TestServerPingRetry(t *testing.T) {
+	config := DefaultConfig().Merge(&Config{ServerPing: true, HeartbeatInterval: 30 * time.Second, HeartbeatMaxRetry: 3})
+	if config.ReadTimeout < 5*30*time.Second || config.HeartbeatTimeout < 5*30*time.Second {
+		t.Fatalf("超时时间小于重试窗口：%v %v", config.ReadTimeout, config.HeartbeatTimeout)
+	}
+
+	m, url := newTestManager(t, &Config{ServerPing: true, HeartbeatInterval: 50 * time.Millisecond, HeartbeatMaxRetry: 2})
+	disconnected := make(chan disconnectEvent, 1)
+	m.OnDisconnect = func(connID string, err error, last bool) {
+		disconnected <- disconnectEvent{connID, err, last}
+	}
+	conn := dial(t, url, "silent")
+	conn.SetPingHandler(func(string) error { return nil })
+	drain(conn)
+	select {
+	case event := <-disconnected:
+		if event.err == nil || !strings.Contains(event.err.Error(), "未响应ping") {
+			t.Fatalf("应按重试次数断开：%v", event.err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("不响应ping的连接未断开")
+	}
+}
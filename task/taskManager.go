@@ -68,6 +68,28 @@ func (m *sTaskManager) GetTask(token string) (*Task, bool) {
 	return task, exists
 }
 
+// SnapshotTask 获取任务的副本，可在UpdateProgress并发更新时安全读取
+func (m *sTaskManager) SnapshotTask(token string) (Task, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	task, exists := m.tasks[token]
+	if !exists {
+		return Task{}, false
+	}
+	return *task, true
+}
+
+// ListTasks 获取所有任务的副本
+func (m *sTaskManager) ListTasks() map[string]Task {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	tasks := make(map[string]Task, len(m.tasks))
+	for token, task := range m.tasks {
+		tasks[token] = *task
+	}
+	return tasks
+}
+
 // RemoveTask 移除任务
 func (m *sTaskManager) RemoveTask(token string) {
 	m.mutex.Lock()
@@ -69,8 +69,8 @@ func (s *TCPServer) SetMessageHandler(handler MessageHandler) {
 // Start 启动TCP服务器
 func (s *TCPServer) Start() error {
 	s.Logger.Info(s.ctx, fmt.Sprintf("TCP server starting on %s", s.Address))
+	s.wg.Add(1)
 	go func() {
-		s.wg.Add(1)
 		defer s.wg.Done()
 		if err := s.Listener.Run(); err != nil {
 			s.Logger.Error(s.ctx, fmt.Sprintf("TCP server stopped with error: %v", err))
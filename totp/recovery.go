@@ -0,0 +1,78 @@
+package totp
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"github.com/black1552/base-common/utils"
+	"github.com/gogf/gf/v2/text/gstr"
+)
+
+// recoveryAlphabet 恢复码字符，去除了易混淆字符
+const recoveryAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// GenerateRecoveryCodes 生成一次性恢复码
+/*
+ * 明文仅展示给用户一次，数据库只保存哈希
+ * @param n int 数量，默认10
+ * @return codes []string 明文，格式xxxxx-xxxxx
+ * @return hashes []string 哈希，与codes一一对应
+ */
+func GenerateRecoveryCodes(n int) (codes []string, hashes []string, err error) {
+	if n <= 0 {
+		n = 10
+	}
+	max := big.NewInt(int64(len(recoveryAlphabet)))
+	for i := 0; i < n; i++ {
+		b := make([]byte, 10)
+		for j := range b {
+			index, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, nil, err
+			}
+			b[j] = recoveryAlphabet[index.Int64()]
+		}
+		code := string(b[:5]) + "-" + string(b[5:])
+		hash, err := HashRecoveryCode(code)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hash)
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode 使用utils.HashPassword计算恢复码哈希（加盐），忽略大小写、空格和连字符
+func HashRecoveryCode(code string) (string, error) {
+	return utils.HashPassword(normalizeRecoveryCode(code))
+}
+
+// normalizeRecoveryCode 统一恢复码格式
+func normalizeRecoveryCode(code string) string {
+	return gstr.ToLower(gstr.TrimAll(code, "-"))
+}
+
+// UseRecoveryCode 校验恢复码，成功时返回去除该恢复码后的哈希列表，调用方需保存以保证一次性
+/*
+ * @param code string 用户输入的恢复码
+ * @param hashes []string 已保存的哈希
+ * @return []string 剩余的哈希
+ * @return bool 是否有效
+ */
+func UseRecoveryCode(code string, hashes []string) ([]string, bool) {
+	code = normalizeRecoveryCode(code)
+	if code == "" {
+		return hashes, false
+	}
+	for i, h := range hashes {
+		// 哈希带有各自的盐，逐个校验，比较为常量时间
+		if ok, _, err := utils.VerifyPassword(code, h); err == nil && ok {
+			remain := make([]string, 0, len(hashes)-1)
+			remain = append(remain, hashes[:i]...)
+			remain = append(remain, hashes[i+1:]...)
+			return remain, true
+		}
+	}
+	return hashes, false
+}
@@ -0,0 +1,226 @@
+package totp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gogf/gf/v2/os/gcache"
+	"github.com/gogf/gf/v2/text/gstr"
+	"github.com/skip2/go-qrcode"
+)
+
+// encoding 密钥编码，base32无填充
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Config TOTP配置，默认值与Google Authenticator等常见应用兼容
+type Config struct {
+	Issuer string        // 签发方，显示在身份验证器中
+	Digits int           // 验证码位数，默认6
+	Period time.Duration // 时间步长，默认30秒
+	Skew   int           // 允许的前后时间步数，用于容忍时钟误差，默认1
+	Replay ReplayStore   // 已使用验证码的存储，用于防止重放，默认内存
+}
+
+// ReplayStore 已使用验证码的存储，集群部署时需使用共享存储
+type ReplayStore interface {
+	// Use 标记账号在指定时间步的验证码已使用，时间步不大于该账号已使用的时间步时返回false
+	// 时钟误差窗口内较早时间步的验证码在较晚的验证码使用后同样失效
+	Use(ctx context.Context, account string, counter int64, ttl time.Duration) (bool, error)
+}
+
+// MemoryReplayStore 内存存储，记录每个账号最后使用的时间步
+type MemoryReplayStore struct {
+	cache *gcache.Cache
+	mutex sync.Mutex
+}
+
+// NewMemoryReplayStore 创建内存存储
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{cache: gcache.New()}
+}
+
+// Use 标记验证码已使用
+func (m *MemoryReplayStore) Use(ctx context.Context, account string, counter int64, ttl time.Duration) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	last, err := m.cache.Get(ctx, account)
+	if err != nil {
+		return false, err
+	}
+	if !last.IsNil() && counter <= last.Int64() {
+		return false, nil
+	}
+	return true, m.cache.Set(ctx, account, counter, ttl)
+}
+
+// TOTP 基于时间的一次性密码（RFC 6238）
+type TOTP struct {
+	config Config
+	mutex  sync.RWMutex
+}
+
+// Default 默认TOTP服务
+var Default = New(Config{})
+
+// New 创建TOTP服务
+func New(config Config) *TOTP {
+	t := &TOTP{}
+	t.Init(config)
+	return t
+}
+
+// Init 设置配置，未设置的项使用默认值
+func (t *TOTP) Init(config Config) {
+	if config.Digits <= 0 {
+		config.Digits = 6
+	}
+	if config.Period <= 0 {
+		config.Period = 30 * time.Second
+	}
+	if config.Skew < 0 {
+		config.Skew = 0
+	} else if config.Skew == 0 {
+		config.Skew = 1
+	}
+	if config.Replay == nil {
+		config.Replay = NewMemoryReplayStore()
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.config = config
+}
+
+// getConfig 获取当前配置
+func (t *TOTP) getConfig() Config {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.config
+}
+
+// GenerateSecret 生成160位随机密钥，base32编码
+func GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// URI 生成身份验证器绑定使用的otpauth地址
+/*
+ * @param account string 账号，如用户名或邮箱
+ * @param secret string 密钥
+ * @return string otpauth://totp/签发方:账号?secret=...&issuer=...
+ */
+func (t *TOTP) URI(account string, secret string) string {
+	config := t.getConfig()
+	label := url.PathEscape(account)
+	query := url.Values{}
+	query.Set("secret", secret)
+	if config.Issuer != "" {
+		label = url.PathEscape(config.Issuer) + ":" + label
+		query.Set("issuer", config.Issuer)
+	}
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(config.Digits))
+	query.Set("period", fmt.Sprint(int(config.Period/time.Second)))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// QRCode 生成otpauth地址的二维码PNG
+/*
+ * @param account string 账号
+ * @param secret string 密钥
+ * @param size int 图片边长，默认256
+ * @return []byte PNG图片内容
+ */
+func (t *TOTP) QRCode(account string, secret string, size ...int) ([]byte, error) {
+	s := 256
+	if len(size) > 0 && size[0] > 0 {
+		s = size[0]
+	}
+	return qrcode.Encode(t.URI(account, secret), qrcode.Medium, s)
+}
+
+// Code 计算指定时间的验证码
+func (t *TOTP) Code(secret string, at time.Time) (string, error) {
+	config := t.getConfig()
+	return hotp(secret, at.Unix()/int64(config.Period/time.Second), config.Digits)
+}
+
+// Validate 校验验证码是否在允许的时间窗口内，不检查重放
+/*
+ * @return int64 匹配的时间步
+ * @return bool 是否有效
+ */
+func (t *TOTP) Validate(secret string, code string, at time.Time) (int64, bool) {
+	config := t.getConfig()
+	code = gstr.Trim(code)
+	if len(code) != config.Digits {
+		return 0, false
+	}
+	counter := at.Unix() / int64(config.Period/time.Second)
+	for i := -config.Skew; i <= config.Skew; i++ {
+		expected, err := hotp(secret, counter+int64(i), config.Digits)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter + int64(i), true
+		}
+	}
+	return 0, false
+}
+
+// Verify 校验验证码，同一账号的同一验证码只能使用一次
+/*
+ * @param ctx context.Context 上下文
+ * @param account string 账号，用于防重放
+ * @param secret string 密钥
+ * @param code string 用户输入的验证码
+ * @return bool 是否有效
+ */
+func (t *TOTP) Verify(ctx context.Context, account string, secret string, code string) bool {
+	counter, ok := t.Validate(secret, code, time.Now())
+	if !ok {
+		return false
+	}
+	config := t.getConfig()
+	ttl := config.Period * time.Duration(2*config.Skew+2)
+	unused, err := config.Replay.Use(ctx, account, counter, ttl)
+	return err == nil && unused
+}
+
+// hotp 计算HOTP（RFC 4226）
+func hotp(secret string, counter int64, digits int) (string, error) {
+	key, err := encoding.DecodeString(gstr.ToUpper(gstr.TrimAll(secret)))
+	if err != nil {
+		return "", fmt.Errorf("密钥格式错误: %w", err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod), nil
+}
+
+// Verify 使用默认服务校验验证码
+func Verify(ctx context.Context, account string, secret string, code string) bool {
+	return Default.Verify(ctx, account, secret, code)
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"sync"
 
 	"github.com/gogf/gf/v2/container/gmap"
 	"github.com/gogf/gf/v2/container/gvar"
@@ -11,6 +12,7 @@ import (
 	"github.com/gogf/gf/v2/os/glog"
 	"github.com/gogf/gf/v2/text/gstr"
 	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/gogf/gf/v2/util/gutil"
 )
 
 type ctx = context.Context
@@ -27,6 +29,55 @@ type Curd[R any] struct {
 	Dao IDao
 }
 
+// WriteHook 数据写入（新增、修改、删除）成功后的回调，table为表名
+type WriteHook func(ctx context.Context, table string)
+
+var (
+	writeHooks     []WriteHook
+	writeHookMutex sync.RWMutex
+)
+
+// OnWrite 注册数据写入回调，可用于清理缓存等
+func OnWrite(hook WriteHook) {
+	writeHookMutex.Lock()
+	defer writeHookMutex.Unlock()
+	writeHooks = append(writeHooks, hook)
+}
+
+// afterWrite 触发数据写入回调
+func (c Curd[R]) afterWrite(ctx context.Context) {
+	writeHookMutex.RLock()
+	hooks := writeHooks
+	writeHookMutex.RUnlock()
+	for _, hook := range hooks {
+		hook(ctx, c.Dao.Table())
+	}
+}
+
+// masterCtxKey 强制主库查询的上下文键
+type masterCtxKey struct{}
+
+// WithMaster 返回强制走主库的上下文，用于写入后立即读取等需要读写一致的场景
+// 例：ctx = utils.WithMaster(ctx); curd.Save(ctx, data); curd.FindPri(ctx, id, false)
+func WithMaster(ctx context.Context) context.Context {
+	return context.WithValue(ctx, masterCtxKey{}, true)
+}
+
+// IsMaster 判断上下文是否强制走主库
+func IsMaster(ctx context.Context) bool {
+	master, _ := ctx.Value(masterCtxKey{}).(bool)
+	return master
+}
+
+// model 获取查询模型，配置从库时查询默认走从库，上下文强制主库时走主库
+func (c Curd[R]) model(ctx context.Context) *gdb.Model {
+	m := c.Dao.Ctx(ctx)
+	if IsMaster(ctx) {
+		m = m.Master()
+	}
+	return m
+}
+
 var pageInfo = []string{
 	"page",
 	"size",
@@ -148,7 +199,7 @@ func (c Curd[R]) BuildMap(op string, value any, field ...string) map[string]any
 	}
 }
 func (c Curd[R]) Builder(ctx context.Context) *gdb.WhereBuilder {
-	return c.Dao.Ctx(ctx).Builder()
+	return c.model(ctx).Builder()
 }
 func (c Curd[R]) ClearField(req any, delField []string, subField ...map[string]any) map[string]any {
 	m := gmap.NewStrAnyMapFrom(gconv.Map(req))
@@ -175,7 +226,7 @@ func (c Curd[R]) ClearField(req any, delField []string, subField ...map[string]a
 	return m.Map()
 }
 func (c Curd[R]) ClearFieldPage(ctx ctx, req any, delField []string, where any, page *Paginate, order any, with bool) (items []*R, total int, err error) {
-	db := c.Dao.Ctx(ctx)
+	db := c.model(ctx)
 	m := c.ClearField(req, delField)
 	if with {
 		db = db.WithAll()
@@ -194,7 +245,7 @@ func (c Curd[R]) ClearFieldPage(ctx ctx, req any, delField []string, where any,
 	return
 }
 func (c Curd[R]) ClearFieldList(ctx ctx, req any, delField []string, where any, order any, with bool) (items []*R, err error) {
-	db := c.Dao.Ctx(ctx)
+	db := c.model(ctx)
 	m := c.ClearField(req, delField)
 	db = db.Where(m)
 	if !g.IsNil(where) {
@@ -210,7 +261,7 @@ func (c Curd[R]) ClearFieldList(ctx ctx, req any, delField []string, where any,
 	return
 }
 func (c Curd[R]) ClearFieldOne(ctx ctx, req any, delField []string, where any, order any, with bool) (items *R, err error) {
-	db := c.Dao.Ctx(ctx)
+	db := c.model(ctx)
 	m := c.ClearField(req, delField)
 	db = db.Where(m)
 	if !g.IsNil(where) {
@@ -226,30 +277,36 @@ func (c Curd[R]) ClearFieldOne(ctx ctx, req any, delField []string, where any, o
 	return
 }
 func (c Curd[R]) Value(ctx ctx, where any, field any) (*gvar.Var, error) {
-	return c.Dao.Ctx(ctx).Where(where).Fields(field).Value()
+	return c.model(ctx).Where(where).Fields(field).Value()
 }
 func (c Curd[R]) DeletePri(ctx ctx, primaryKey any) error {
 	_, err := c.Dao.Ctx(ctx).WherePri(primaryKey).Delete()
+	if err == nil {
+		c.afterWrite(ctx)
+	}
 	return err
 }
 func (c Curd[R]) DeleteWhere(ctx ctx, where any) error {
 	_, err := c.Dao.Ctx(ctx).Where(where).Delete()
+	if err == nil {
+		c.afterWrite(ctx)
+	}
 	return err
 }
 
 func (c Curd[R]) Sum(ctx ctx, where any, field string) (float64, error) {
-	return c.Dao.Ctx(ctx).Where(where).Sum(field)
+	return c.model(ctx).Where(where).Sum(field)
 }
 
 func (c Curd[R]) ArrayField(ctx ctx, where any, field any) ([]*gvar.Var, error) {
 	if field == nil {
 		field = "*"
 	}
-	return c.Dao.Ctx(ctx).Where(where).Fields(field).Array()
+	return c.model(ctx).Where(where).Fields(field).Array()
 }
 
 func (c Curd[R]) FindPri(ctx ctx, primaryKey any, with bool) (model *R, err error) {
-	db := c.Dao.Ctx(ctx).WherePri(primaryKey)
+	db := c.model(ctx).WherePri(primaryKey)
 	if with {
 		db = db.WithAll()
 	}
@@ -261,7 +318,7 @@ func (c Curd[R]) FindPri(ctx ctx, primaryKey any, with bool) (model *R, err erro
 }
 
 func (c Curd[R]) First(ctx ctx, where any, order any, with bool) (model *R, err error) {
-	db := c.Dao.Ctx(ctx).Where(where)
+	db := c.model(ctx).Where(where)
 	if with {
 		db = db.WithAll()
 	}
@@ -276,11 +333,11 @@ func (c Curd[R]) First(ctx ctx, where any, order any, with bool) (model *R, err
 }
 
 func (c Curd[R]) Exists(ctx ctx, where any) (exists bool, err error) {
-	return c.Dao.Ctx(ctx).Where(where).Exist()
+	return c.model(ctx).Where(where).Exist()
 }
 
 func (c Curd[R]) All(ctx ctx, where any, order any, with bool) (items []*R, err error) {
-	db := c.Dao.Ctx(ctx)
+	db := c.model(ctx)
 	if with {
 		db = db.WithAll()
 	}
@@ -295,24 +352,65 @@ func (c Curd[R]) All(ctx ctx, where any, order any, with bool) (items []*R, err
 }
 
 func (c Curd[R]) Count(ctx ctx, where any) (count int, err error) {
-	count, err = c.Dao.Ctx(ctx).Where(where).Count()
+	count, err = c.model(ctx).Where(where).Count()
 	return
 }
 
 func (c Curd[R]) Save(ctx ctx, data any) (id int64, err error) {
+	if c.Dao.DB().GetConfig().Type == "pgsql" {
+		return c.savePgsql(ctx, data)
+	}
 	result, err := c.Dao.Ctx(ctx).Save(data)
 	if err != nil {
 		return
 	}
+	c.afterWrite(ctx)
 	id, err = result.LastInsertId()
 	return
 }
 
+// savePgsql Postgres不支持LastInsertId，且upsert必须指定冲突字段
+// 数据包含主键时按主键upsert并返回主键，否则新增并通过RETURNING获取主键
+func (c Curd[R]) savePgsql(ctx ctx, data any) (id int64, err error) {
+	var (
+		primaryKey string
+		pkValue    any
+	)
+	fields, err := c.Dao.DB().TableFields(ctx, c.Dao.Table())
+	if err != nil {
+		return
+	}
+	for _, field := range fields {
+		if field.Key == "pri" {
+			primaryKey = field.Name
+			break
+		}
+	}
+	if primaryKey != "" {
+		_, pkValue = gutil.MapPossibleItemByKey(gconv.Map(data), primaryKey)
+	}
+	if g.IsEmpty(pkValue) {
+		result, err := c.Dao.Ctx(ctx).Insert(data)
+		if err != nil {
+			return 0, err
+		}
+		c.afterWrite(ctx)
+		return result.LastInsertId()
+	}
+	if _, err = c.Dao.Ctx(ctx).OnConflict(primaryKey).Save(data); err != nil {
+		return
+	}
+	c.afterWrite(ctx)
+	id = gconv.Int64(pkValue)
+	return
+}
+
 func (c Curd[R]) Update(ctx ctx, where any, data any) (count int64, err error) {
 	result, err := c.Dao.Ctx(ctx).Where(where).Data(data).Update()
 	if err != nil {
 		return
 	}
+	c.afterWrite(ctx)
 	count, err = result.RowsAffected()
 	return
 }
@@ -322,12 +420,13 @@ func (c Curd[R]) UpdatePri(ctx ctx, primaryKey any, data any) (count int64, err
 	if err != nil {
 		return
 	}
+	c.afterWrite(ctx)
 	count, err = result.RowsAffected()
 	return
 }
 
 func (c Curd[R]) Paginate(ctx context.Context, where any, p Paginate, with bool, order any) (items []*R, total int, err error) {
-	query := c.Dao.Ctx(ctx)
+	query := c.model(ctx)
 	if where != nil {
 		query = query.Where(where)
 	}
@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownHash 无法识别的密码哈希格式
+var ErrUnknownHash = errors.New("无法识别的密码哈希格式")
+
+// PasswordHasher 密码哈希算法
+type PasswordHasher interface {
+	// Hash 计算密码哈希，返回包含算法参数的字符串
+	Hash(password string) (string, error)
+	// Verify 校验密码，encoded不是本算法的哈希时返回ErrUnknownHash
+	Verify(password string, encoded string) (bool, error)
+	// NeedsRehash 哈希的算法或参数与当前配置不一致时返回true
+	NeedsRehash(encoded string) bool
+}
+
+// DefaultPasswordHasher 默认密码哈希算法，HashPassword使用
+var DefaultPasswordHasher PasswordHasher = NewArgon2idHasher()
+
+// passwordHashers 可校验的全部算法，用于校验历史数据
+var passwordHashers = []PasswordHasher{NewArgon2idHasher(), NewBcryptHasher()}
+
+// BcryptHasher bcrypt算法，格式$2a$10$...
+type BcryptHasher struct {
+	Cost int // 计算成本，默认bcrypt.DefaultCost
+}
+
+// NewBcryptHasher 创建bcrypt算法
+func NewBcryptHasher(cost ...int) *BcryptHasher {
+	h := &BcryptHasher{Cost: bcrypt.DefaultCost}
+	if len(cost) > 0 && cost[0] >= bcrypt.MinCost {
+		h.Cost = cost[0]
+	}
+	return h
+}
+
+// Hash 计算密码哈希
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(hash), err
+}
+
+// Verify 校验密码
+func (h *BcryptHasher) Verify(password string, encoded string) (bool, error) {
+	if !isBcrypt(encoded) {
+		return false, ErrUnknownHash
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// NeedsRehash 判断是否需要重新计算
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	if !isBcrypt(encoded) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.Cost
+}
+
+// isBcrypt 是否为bcrypt哈希
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// Argon2idHasher argon2id算法，PHC格式$argon2id$v=19$m=65536,t=3,p=2$盐$哈希
+type Argon2idHasher struct {
+	Memory      uint32 // 内存，单位KiB
+	Iterations  uint32 // 迭代次数
+	Parallelism uint8  // 并行度
+	SaltLength  uint32 // 盐长度
+	KeyLength   uint32 // 哈希长度
+}
+
+// NewArgon2idHasher 创建argon2id算法，默认参数参考OWASP建议
+func NewArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// argon2idParams 解析后的argon2id哈希
+type argon2idParams struct {
+	memory, iterations uint32
+	parallelism        uint8
+	salt, key          []byte
+}
+
+// Hash 计算密码哈希
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify 校验密码
+func (h *Argon2idHasher) Verify(password string, encoded string) (bool, error) {
+	params, err := parseArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+	return subtle.ConstantTimeCompare(key, params.key) == 1, nil
+}
+
+// NeedsRehash 判断是否需要重新计算
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, err := parseArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.memory != h.Memory || params.iterations != h.Iterations ||
+		params.parallelism != h.Parallelism || uint32(len(params.salt)) != h.SaltLength ||
+		uint32(len(params.key)) != h.KeyLength
+}
+
+// parseArgon2id 解析argon2id的PHC字符串
+func parseArgon2id(encoded string) (*argon2idParams, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, ErrUnknownHash
+	}
+	var (
+		version int
+		params  = &argon2idParams{}
+		err     error
+	)
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, fmt.Errorf("不支持的argon2版本: %s", parts[2])
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, fmt.Errorf("argon2参数错误: %w", err)
+	}
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("argon2盐格式错误: %w", err)
+	}
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, fmt.Errorf("argon2哈希格式错误: %w", err)
+	}
+	return params, nil
+}
+
+// HashPassword 使用默认算法计算密码哈希
+func HashPassword(password string) (string, error) {
+	return DefaultPasswordHasher.Hash(password)
+}
+
+// NeedsRehash 哈希不是默认算法或参数已变更时返回true，旧的GetCapitalPass哈希始终返回true
+func NeedsRehash(encoded string) bool {
+	return DefaultPasswordHasher.NeedsRehash(encoded)
+}
+
+// VerifyPassword 校验密码，兼容GetCapitalPass生成的旧哈希
+/*
+ * 校验通过且哈希需要升级时返回新哈希，调用方应在登录成功后保存，例：
+ *	ok, newHash, err := utils.VerifyPassword(req.Password, user.Password)
+ *	if ok && newHash != "" { dao.User.Ctx(ctx).WherePri(user.Id).Data("password", newHash).Update() }
+ * @param password string 用户输入的密码
+ * @param encoded string 已保存的哈希
+ * @return ok bool 密码是否正确
+ * @return newHash string 需要升级时的新哈希，否则为空
+ */
+func VerifyPassword(password string, encoded string) (ok bool, newHash string, err error) {
+	if strings.HasPrefix(encoded, "$") {
+		err = ErrUnknownHash
+		for _, hasher := range append([]PasswordHasher{DefaultPasswordHasher}, passwordHashers...) {
+			if ok, err = hasher.Verify(password, encoded); !errors.Is(err, ErrUnknownHash) {
+				break
+			}
+		}
+		if err != nil || !ok {
+			return false, "", err
+		}
+	} else if subtle.ConstantTimeCompare([]byte(GetCapitalPass(password)), []byte(encoded)) != 1 {
+		return false, "", nil
+	}
+	if NeedsRehash(encoded) {
+		if newHash, err = HashPassword(password); err != nil {
+			// 升级失败不影响本次登录
+			return true, "", nil
+		}
+	}
+	return true, newHash, nil
+}
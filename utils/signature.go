@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gogf/gf/v2/net/gclient"
+)
+
+// 签名请求头
+const (
+	SignAppKeyHeader    = "X-App-Key"
+	SignTimestampHeader = "X-Timestamp"
+	SignNonceHeader     = "X-Nonce"
+	SignatureHeader     = "X-Signature"
+)
+
+// SignCanonical 生成待签名字符串
+/*
+ * 格式（以\n连接）：
+ *	大写请求方法
+ *	请求路径
+ *	按键、值排序并编码后的查询参数
+ *	请求体sha256（十六进制小写）
+ *	时间戳（秒）
+ *	随机串
+ */
+func SignCanonical(method, path, rawQuery string, body []byte, timestamp, nonce string) string {
+	if path == "" {
+		path = "/"
+	}
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		sortedQuery(rawQuery),
+		hex.EncodeToString(bodyHash[:]),
+		timestamp,
+		nonce,
+	}, "\n")
+}
+
+// sortedQuery 查询参数按键、值排序
+func sortedQuery(rawQuery string) string {
+	values, _ := url.ParseQuery(rawQuery)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	items := make([]string, 0, len(values))
+	for _, k := range keys {
+		vs := append([]string(nil), values[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			items = append(items, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	return strings.Join(items, "&")
+}
+
+// SignHmac 使用密钥计算HMAC-SHA256签名（十六进制小写）
+func SignHmac(secret, canonical string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Signer 请求签名器，用于调用使用相同签名方式的第三方接口
+type Signer struct {
+	AppKey    string
+	AppSecret string
+}
+
+// NewSigner 创建请求签名器
+func NewSigner(appKey, appSecret string) *Signer {
+	return &Signer{AppKey: appKey, AppSecret: appSecret}
+}
+
+// Sign 为请求添加签名头，会读取并还原请求体
+func (s *Signer) Sign(r *http.Request) error {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return err
+		}
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	var (
+		timestamp = strconv.FormatInt(time.Now().Unix(), 10)
+		nonceStr  = hex.EncodeToString(nonce)
+	)
+	r.Header.Set(SignAppKeyHeader, s.AppKey)
+	r.Header.Set(SignTimestampHeader, timestamp)
+	r.Header.Set(SignNonceHeader, nonceStr)
+	r.Header.Set(SignatureHeader, SignHmac(s.AppSecret, SignCanonical(r.Method, r.URL.EscapedPath(), r.URL.RawQuery, body, timestamp, nonceStr)))
+	return nil
+}
+
+// Middleware gclient中间件，例：g.Client().Use(signer.Middleware)
+func (s *Signer) Middleware(c *gclient.Client, r *http.Request) (*gclient.Response, error) {
+	if err := s.Sign(r); err != nil {
+		return nil, err
+	}
+	return c.Next(r)
+}
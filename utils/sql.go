@@ -4,6 +4,8 @@ import (
 	"context"
 
 	_ "github.com/gogf/gf/contrib/drivers/mysql/v2"
+	_ "github.com/gogf/gf/contrib/drivers/pgsql/v2"
+	_ "github.com/gogf/gf/contrib/drivers/sqlite/v2"
 	"github.com/gogf/gf/v2/crypto/gmd5"
 	"github.com/gogf/gf/v2/database/gdb"
 	"github.com/gogf/gf/v2/frame/g"
@@ -13,6 +15,8 @@ import (
 )
 
 // GetCapitalPass MD5化并转换为大写
+//
+// Deprecated: MD5无盐且计算过快，新密码请使用HashPassword，校验使用VerifyPassword（兼容旧哈希并自动升级）
 func GetCapitalPass(val string) string {
 	md5, err := gmd5.Encrypt(val)
 	if err != nil {
@@ -50,6 +54,13 @@ func NewClient[R any](request any, url string, header map[string]string) *SClien
 	s.request = request
 	return s
 }
+
+// WithSigner 使用签名器为请求添加签名
+func (w *SClient[R]) WithSigner(signer *Signer) *SClient[R] {
+	w.client.Use(signer.Middleware)
+	return w
+}
+
 func (w *SClient[R]) Post(ctx context.Context) (res *R, err error) {
 	g.Log().Infof(ctx, "请求Url:%s,请求头:%v,请求方法：%s,请求内容：%s", w.url, w.header, "post", w.request)
 	resp := w.client.PostVar(ctx, w.url, w.request)